@@ -0,0 +1,45 @@
+package conf
+
+import (
+	"sync"
+)
+
+//Loads each config in items independently, using map keys as RootPath.
+//Files shared between items are read once. Returns errors of failed
+//items keyed the same way; items missing from the result loaded fine.
+func (l *Loader) LoadBatch(items map[string]interface{}) map[string]error {
+	type cached struct {
+		data []byte
+		err  error
+	}
+	cache := map[string]cached{}
+	var mu sync.Mutex
+
+	readFile := l.readFileFunc()
+	cachedRead := func(path string) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		entry, ok := cache[path]
+		if !ok {
+			data, err := readFile(path)
+			entry = cached{data, err}
+			cache[path] = entry
+		}
+		return entry.data, entry.err
+	}
+
+	errs := map[string]error{}
+	for rootPath, config := range items {
+		item := *l
+		item.RootPath = rootPath
+		item.ReadFileFunc = cachedRead
+
+		err := item.Load(config)
+		if err != nil {
+			errs[rootPath] = err
+		}
+	}
+
+	return errs
+}
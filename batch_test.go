@@ -0,0 +1,46 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"shared.json":      `{"region": "eu"}`,
+		"api/config.json":  `{"name": "api"}`,
+		"web/config.json":  `{"name": "web"}`,
+		"jobs/config.json": `{"name":`,
+	})
+
+	reads := map[string]int{}
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	loader.OverridePath = filepath.Join(dir, "shared.json")
+	loader.ReadFileFunc = func(path string) ([]byte, error) {
+		reads[path]++
+		return os.ReadFile(path)
+	}
+
+	type config struct {
+		Name   string `json:"name"`
+		Region string `json:"region"`
+	}
+	api, web, jobs := &config{}, &config{}, &config{}
+	errs := loader.LoadBatch(map[string]interface{}{
+		filepath.Join(dir, "api"):  api,
+		filepath.Join(dir, "web"):  web,
+		filepath.Join(dir, "jobs"): jobs,
+	})
+
+	if len(errs) != 1 || errs[filepath.Join(dir, "jobs")] == nil {
+		t.Errorf("got errors %v, want only jobs failing", errs)
+	}
+	if api.Name != "api" || api.Region != "eu" || web.Name != "web" || web.Region != "eu" {
+		t.Errorf("got api %+v, web %+v", api, web)
+	}
+	if n := reads[filepath.Join(dir, "shared.json")]; n != 1 {
+		t.Errorf("shared file read %d times, want once", n)
+	}
+}
@@ -0,0 +1,144 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type typedTransform func(value interface{}, t reflect.Type, key string) (interface{}, error)
+
+//Walks generic values decoded from JSON along with the Go type they
+//decode into, replacing each value with the result of fn.
+func transformTyped(value interface{}, t reflect.Type, key string, fn typedTransform) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	value, err := fn(value, t, key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, item := range v {
+			var itemType reflect.Type
+			switch t.Kind() {
+			case reflect.Struct:
+				field, ok := structFieldByKey(t, name)
+				if !ok {
+					continue
+				}
+				itemType = field.Type
+			case reflect.Map:
+				itemType = t.Elem()
+			default:
+				continue
+			}
+
+			v[name], err = transformTyped(item, itemType, joinKey(key, name), fn)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			break
+		}
+		for i, item := range v {
+			v[i], err = transformTyped(item, t.Elem(), fmt.Sprintf("%s[%d]", key, i), fn)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return value, nil
+}
+
+//Finds field of struct type t the way encoding/json matches keys.
+func structFieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	var folded reflect.StructField
+	found := false
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			if embedded, ok := structFieldByKey(field.Type, key); ok {
+				return embedded, true
+			}
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		if name == key {
+			return field, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			folded, found = field, true
+		}
+	}
+
+	return folded, found
+}
+
+//Converts strings targeting numeric and bool fields.
+func coerceStringNumbers(value interface{}, t reflect.Type, key string) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok || t == durationType {
+		return value, nil
+	}
+
+	var err error
+	switch t.Kind() {
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		if err == nil {
+			return b, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(s, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(s, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(s, t.Bits())
+	default:
+		return value, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("conf: cannot coerce %s value %q to %s", key, s, t)
+	}
+	return json.Number(s), nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+//Returns transform parsing strings targeting time.Time fields
+//with the first matching layout.
+func parseTimeLayouts(layouts []string) typedTransform {
+	return func(value interface{}, t reflect.Type, key string) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok || t != timeType {
+			return value, nil
+		}
+
+		for _, layout := range layouts {
+			parsed, err := time.Parse(layout, s)
+			if err == nil {
+				return parsed.Format(time.RFC3339Nano), nil
+			}
+		}
+
+		return nil, fmt.Errorf("conf: %s value %q matches none of time layouts", key, s)
+	}
+}
@@ -0,0 +1,64 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoerceStringNumbers(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"port": "8080", "debug": "true", "db": {"ratio": "0.5"}}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles|CoerceStringNumbers)
+
+	var config struct {
+		Port  int  `json:"port"`
+		Debug bool `json:"debug"`
+		Db    struct {
+			Ratio float64 `json:"ratio"`
+		} `json:"db"`
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 8080 || !config.Debug || config.Db.Ratio != 0.5 {
+		t.Errorf("got %+v", config)
+	}
+
+	writeFiles(t, dir, map[string]string{"config.json": `{"port": "http"}`})
+	err = loader.Load(&config)
+	if err == nil || err.Error() != dir+`/config.json: conf: cannot coerce port value "http" to int` {
+		t.Errorf("got %v, want coercion error naming port", err)
+	}
+}
+
+func TestTimeLayouts(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"start": "2024-03-15", "db": {"created": "2024-03-15T10:30:00Z"}}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	loader.TimeLayouts = []string{time.RFC3339, "2006-01-02"}
+
+	var config struct {
+		Start time.Time `json:"start"`
+		Db    struct {
+			Created time.Time `json:"created"`
+		} `json:"db"`
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC); !config.Start.Equal(want) {
+		t.Errorf("got start %v, want %v", config.Start, want)
+	}
+	if want := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC); !config.Db.Created.Equal(want) {
+		t.Errorf("got created %v, want %v", config.Db.Created, want)
+	}
+
+	writeFiles(t, dir, map[string]string{"config.json": `{"start": "15/03/2024"}`})
+	err = loader.Load(&config)
+	if err == nil || !strings.Contains(err.Error(), "matches none of time layouts") {
+		t.Errorf("got error %v, want no matching layout", err)
+	}
+}
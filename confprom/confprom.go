@@ -0,0 +1,70 @@
+/*
+Package confprom exposes Prometheus metrics of conf.Loader.
+It is a separate module so the conf package stays free of Prometheus dependencies.
+*/
+package confprom
+
+import (
+	"time"
+
+	"github.com/liquidm/go-conf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//Returns collectors counting loads, skipped config files and failed
+//loads of loader, and observing load durations. They are populated
+//through OnLoad, OnSkip and OnComplete hooks of loader, hooks set
+//before are still called.
+func Collectors(loader *conf.Loader) []prometheus.Collector {
+	loads := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "conf_loads_total",
+		Help: "Number of config loads.",
+	})
+	loadedFiles := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "conf_loaded_files_total",
+		Help: "Number of config files loaded.",
+	})
+	skippedFiles := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "conf_skipped_files_total",
+		Help: "Number of config files skipped.",
+	})
+	errors := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "conf_load_errors_total",
+		Help: "Number of config loads which failed.",
+	})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "conf_load_duration_seconds",
+		Help:    "Duration of config loads.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 4, 8),
+	})
+
+	onLoad := loader.OnLoad
+	loader.OnLoad = func(path string) {
+		loadedFiles.Inc()
+		if onLoad != nil {
+			onLoad(path)
+		}
+	}
+
+	onSkip := loader.OnSkip
+	loader.OnSkip = func(path string, reason error) {
+		skippedFiles.Inc()
+		if onSkip != nil {
+			onSkip(path, reason)
+		}
+	}
+
+	onComplete := loader.OnComplete
+	loader.OnComplete = func(d time.Duration, err error) {
+		loads.Inc()
+		if err != nil {
+			errors.Inc()
+		}
+		duration.Observe(d.Seconds())
+		if onComplete != nil {
+			onComplete(d, err)
+		}
+	}
+
+	return []prometheus.Collector{loads, loadedFiles, skippedFiles, errors, duration}
+}
@@ -0,0 +1,68 @@
+package confprom
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liquidm/go-conf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestCollectors(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"a": 1}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loader, err := conf.NewLoader(conf.UseTest | conf.IgnoreMissingFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.RootPath = dir
+	loader.IsTestFunc = func() bool { return true }
+
+	loaded := 0
+	loader.OnLoad = func(path string) {
+		loaded++
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(Collectors(loader)...)
+
+	var config map[string]interface{}
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.RootPath = filepath.Join(dir, "missing")
+	loader.InvalidatePaths()
+	loader.IsNotFound = func(err error) bool { return false }
+	if loader.Load(&config) == nil {
+		t.Fatal("expected load of missing config to fail")
+	}
+
+	if loaded != 1 {
+		t.Errorf("previous OnLoad hook called %d times, want 1", loaded)
+	}
+
+	recorder := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	for _, want := range []string{
+		"conf_loads_total 2",
+		"conf_loaded_files_total 1",
+		"conf_skipped_files_total 1",
+		"conf_load_errors_total 1",
+		"conf_load_duration_seconds_count 2",
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("scraped metrics miss %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+//Decoder converts contents of a config file into JSON.
+type Decoder func(data []byte) ([]byte, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]Decoder{
+		".conf": decodeFrontMatter,
+	}
+)
+
+//Extension of MessagePack config files, decoded without converting
+//them into JSON unless a decoder is registered for it.
+const msgpackExt = ".mp"
+
+//Registers decoder for config files with given extension, e.g. ".yml".
+//Files with extensions without registered decoder are decoded as JSON,
+//except .mp files decoded as MessagePack.
+func RegisterDecoder(ext string, decoder Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(ext)] = decoder
+}
+
+func decoderFor(path string) Decoder {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	return decoders[strings.ToLower(filepath.Ext(path))]
+}
+
+//Checks if path is a JSON or MessagePack file or has a registered decoder.
+func hasDecoder(path string) bool {
+	ext := filepath.Ext(path)
+	return strings.EqualFold(ext, ".json") || strings.EqualFold(ext, msgpackExt) || decoderFor(path) != nil
+}
+
+//Reports if config file at path is parsed from binary data,
+//so env and template expansion must not touch it.
+func isBinaryConfig(path string) bool {
+	return decoderFor(path) == nil && strings.EqualFold(filepath.Ext(path), msgpackExt)
+}
+
+//document is a parsed config file.
+type document struct {
+	//values is generic representation of the file. Numbers decoded
+	//from MessagePack are int64, uint64 or float64.
+	values interface{}
+
+	//data is decoded into config by unmarshal.
+	data      []byte
+	unmarshal func(data []byte, v interface{}) error
+
+	//generated is set when data was produced from the file, e.g. by
+	//a decoder, so offsets of decoding errors do not point into it.
+	generated bool
+
+	//parents are files extended with "$extends", the farthest first.
+	//keys are then dotted keys of values set by the file itself.
+	parents []extendedFile
+	keys    []string
+}
+
+//Parses config file at path with decoder registered for its extension,
+//as MessagePack for .mp files or as JSON otherwise.
+func parseDocument(path string, data []byte) (*document, error) {
+	if decoder := decoderFor(path); decoder != nil {
+		converted, err := decoder(data)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := parseJSON(converted)
+		if err != nil {
+			return nil, generatedData(err)
+		}
+		doc.generated = true
+		return doc, nil
+	}
+
+	if strings.EqualFold(filepath.Ext(path), msgpackExt) {
+		return parseMsgpack(data)
+	}
+	return parseJSON(data)
+}
+
+func parseJSON(data []byte) (*document, error) {
+	var values interface{}
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{values: values, data: data, unmarshal: json.Unmarshal}, nil
+}
+
+//Encodes values as JSON decoded into config, once they were changed.
+func (d *document) reencode() error {
+	data, err := json.Marshal(d.values)
+	if err != nil {
+		return err
+	}
+
+	d.data = data
+	d.unmarshal = json.Unmarshal
+	d.generated = true
+	return nil
+}
+
+//Decodes YAML front matter delimited by --- lines
+//at the beginning of data, ignoring the rest.
+func decodeFrontMatter(data []byte) ([]byte, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) == 0 || lines[0] != "---" {
+		return nil, errors.New("conf: front matter must start with ---")
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if lines[i] == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, errors.New("conf: front matter is not terminated with ---")
+	}
+
+	var values interface{}
+	err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "\n")), &values)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	return json.Marshal(values)
+}
+
+func parseMsgpack(data []byte) (*document, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.UseLooseInterfaceDecoding(true)
+
+	var values interface{}
+	err := dec.Decode(&values)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{values: values, data: data, unmarshal: unmarshalMsgpack}, nil
+}
+
+//Decodes MessagePack data into v matching struct fields by json tags.
+//Keys are matched exactly. Values MessagePack cannot decode, e.g.
+//strings into time.Time fields, are decoded following JSON rules.
+func unmarshalMsgpack(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	if dec.Decode(v) == nil {
+		return nil
+	}
+
+	doc, err := parseMsgpack(data)
+	if err != nil {
+		return err
+	}
+	err = doc.reencode()
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(doc.data, v)
+	if err != nil {
+		return generatedData(err)
+	}
+	return nil
+}
+
+//Converts JSON config into MessagePack, to be loaded from .mp files.
+//Integral numbers are encoded as integers.
+func CompileMsgpack(jsonData []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.UseNumber()
+
+	var values interface{}
+	err := dec.Decode(&values)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("conf: invalid data after top level JSON value")
+	}
+
+	return msgpack.Marshal(msgpackNumbers(values))
+}
+
+//Replaces json.Number values with int64 or float64.
+func msgpackNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = msgpackNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = msgpackNumbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
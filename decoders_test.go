@@ -0,0 +1,208 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFrontMatterDecoder(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.conf": `---
+name: app
+db:
+  port: 5432
+---
+# App
+
+name: body is ignored
+`})
+	loader := newTestLoader(t, dir, NoMixins)
+	loader.BaseFileNames = []string{"app.conf"}
+
+	var config struct {
+		Name string
+		Db   struct{ Port int }
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Name != "app" || config.Db.Port != 5432 {
+		t.Errorf("got %+v, want front matter values only", config)
+	}
+}
+
+type benchConfig struct {
+	Name     string            `json:"name"`
+	Port     int               `json:"port"`
+	Ratio    float64           `json:"ratio"`
+	Tags     []string          `json:"tags"`
+	Limits   map[string]int    `json:"limits"`
+	Backends []benchBackend    `json:"backends"`
+	Labels   map[string]string `json:"labels"`
+}
+
+type benchBackend struct {
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	Weight  int    `json:"weight"`
+	Enabled bool   `json:"enabled"`
+}
+
+func benchConfigData(b *testing.B) []byte {
+	config := benchConfig{
+		Name:   "bench",
+		Port:   8080,
+		Ratio:  0.25,
+		Limits: map[string]int{},
+		Labels: map[string]string{},
+	}
+	for i := 0; i < 100; i++ {
+		config.Tags = append(config.Tags, fmt.Sprintf("tag-%d", i))
+		config.Limits[fmt.Sprintf("limit-%d", i)] = i
+		config.Labels[fmt.Sprintf("label-%d", i)] = fmt.Sprintf("value-%d", i)
+		config.Backends = append(config.Backends, benchBackend{fmt.Sprintf("10.0.0.%d", i), 9000 + i, i % 5, i%2 == 0})
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func benchmarkLoad(b *testing.B, name string, data []byte) {
+	dir := b.TempDir()
+	err := os.WriteFile(filepath.Join(dir, name), data, 0644)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	loader, err := NewLoader(NoMixins)
+	if err != nil {
+		b.Fatal(err)
+	}
+	loader.RootPath = dir
+	loader.BaseFileNames = []string{name}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var config benchConfig
+		err := loader.Load(&config)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadJSON(b *testing.B) {
+	benchmarkLoad(b, "config.json", benchConfigData(b))
+}
+
+func BenchmarkLoadMsgpack(b *testing.B) {
+	data, err := CompileMsgpack(benchConfigData(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkLoad(b, "config.mp", data)
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	jsonData := []byte(`{
+		"name": "app",
+		"port": 8080,
+		"ratio": 0.5,
+		"started": "2020-01-02T03:04:05Z",
+		"db": {"hosts": ["a", "b"], "limits": {"conns": 10}},
+		"extra": {"debug": true}
+	}`)
+	mpData, err := CompileMsgpack(jsonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": string(jsonData), "config.mp": string(mpData)})
+
+	type config struct {
+		Name    string    `json:"name"`
+		Port    int       `json:"port"`
+		Ratio   float64   `json:"ratio"`
+		Started time.Time `json:"started"`
+		Db      struct {
+			Hosts  []string       `json:"hosts"`
+			Limits map[string]int `json:"limits"`
+		} `json:"db"`
+	}
+
+	var fromJSON, fromMsgpack config
+	for name, c := range map[string]*config{"config.json": &fromJSON, "config.mp": &fromMsgpack} {
+		loader := newTestLoader(t, dir, NoMixins)
+		loader.BaseFileNames = []string{name}
+		err := loader.Load(c)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	if !reflect.DeepEqual(fromJSON, fromMsgpack) {
+		t.Errorf("got %+v from msgpack, want %+v", fromMsgpack, fromJSON)
+	}
+	if fromMsgpack.Port != 8080 || fromMsgpack.Db.Limits["conns"] != 10 || fromMsgpack.Started.Year() != 2020 {
+		t.Errorf("got %+v", fromMsgpack)
+	}
+}
+
+func TestMsgpackSkipsExpansion(t *testing.T) {
+	mpData, err := CompileMsgpack([]byte(`{"path": "${HOME}/app", "name": "{{.name}}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.mp": string(mpData)})
+	t.Setenv("HOME", "/home/app")
+
+	loader := newTestLoader(t, dir, NoMixins|UseEnvExpansion|UseTemplateExpansion)
+	loader.BaseFileNames = []string{"config.mp"}
+	loader.TemplateData = map[string]interface{}{"name": "app"}
+
+	var config struct {
+		Path string `json:"path"`
+		Name string `json:"name"`
+	}
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Path != "${HOME}/app" || config.Name != "{{.name}}" {
+		t.Errorf("got %+v, want values left unexpanded", config)
+	}
+}
+
+func TestStrictExtensions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.jsonn": `{"name": "typo"}`})
+
+	var config struct{ Name string }
+
+	loader := newTestLoader(t, dir, NoMixins)
+	loader.BaseFileNames = []string{"config.jsonn"}
+	err := loader.Load(&config)
+	if err != nil || config.Name != "typo" {
+		t.Errorf("default mode: got %v, %+v, want JSON fallback", err, config)
+	}
+
+	loader = newTestLoader(t, dir, NoMixins|StrictExtensions)
+	loader.BaseFileNames = []string{"config.jsonn"}
+	err = loader.Load(&config)
+	if err == nil || err.Error() != "conf: no decoder for extension of "+filepath.Join(dir, "config.jsonn") {
+		t.Errorf("got %v, want no decoder error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+//Returns the smallest config fragment which merged onto base yields
+//target, that is only keys whose values differ. Nested objects are
+//compared key by key, other values including arrays as a whole.
+//Keys present in base but missing in target are not reported.
+func MinimalOverride(base, target interface{}) (map[string]interface{}, error) {
+	baseValues, err := toObject(base)
+	if err != nil {
+		return nil, err
+	}
+	targetValues, err := toObject(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffObjects(baseValues, targetValues), nil
+}
+
+func toObject(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]interface{}
+	err = json.Unmarshal(data, &object)
+	if err != nil {
+		return nil, errors.New("conf: config must encode to JSON object")
+	}
+
+	return object, nil
+}
+
+func diffObjects(base, target map[string]interface{}) map[string]interface{} {
+	diff := map[string]interface{}{}
+
+	for key, value := range target {
+		baseValue, exists := base[key]
+
+		baseObject, baseIsObject := baseValue.(map[string]interface{})
+		targetObject, targetIsObject := value.(map[string]interface{})
+		if exists && baseIsObject && targetIsObject {
+			if nested := diffObjects(baseObject, targetObject); len(nested) > 0 {
+				diff[key] = nested
+			}
+			continue
+		}
+
+		if !exists || !reflect.DeepEqual(baseValue, value) {
+			diff[key] = value
+		}
+	}
+
+	return diff
+}
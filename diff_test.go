@@ -0,0 +1,45 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMinimalOverride(t *testing.T) {
+	type db struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type config struct {
+		Name  string   `json:"name"`
+		Db    db       `json:"db"`
+		Hosts []string `json:"hosts"`
+		Debug bool     `json:"debug"`
+	}
+
+	base := config{Name: "app", Db: db{"localhost", 5432}, Hosts: []string{"a", "b"}}
+	target := config{Name: "app", Db: db{"db.prod", 5432}, Hosts: []string{"a", "c"}, Debug: true}
+
+	got, err := MinimalOverride(base, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"db":    map[string]interface{}{"host": "db.prod"},
+		"hosts": []interface{}{"a", "c"},
+		"debug": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	merged, err := toObject(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mergeValues(merged, got, false)
+	if wantMerged, _ := toObject(target); !reflect.DeepEqual(merged, wantMerged) {
+		t.Errorf("override merged onto base gives %v, want %v", merged, wantMerged)
+	}
+}
@@ -0,0 +1,125 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//LoadError describes config file that failed to decode.
+type LoadError struct {
+	//Path of the config file.
+	Path string
+
+	//Line and Column of the error location, both starting at 1.
+	//They are zero when location is unknown, e.g. for errors in data
+	//generated by decoders or CoerceStringNumbers flag.
+	Line   int
+	Column int
+
+	//Err is the underlying decoding error.
+	Err error
+
+	data []byte
+}
+
+//Wraps decoding error of data generated from config file, e.g. by
+//a decoder or after coercion, whose offset does not point into the file.
+type generatedDataError struct {
+	err error
+}
+
+func (e *generatedDataError) Error() string {
+	return e.err.Error()
+}
+
+func generatedData(err error) error {
+	if _, ok := err.(*generatedDataError); ok {
+		return err
+	}
+	return &generatedDataError{err}
+}
+
+func newLoadError(path string, data []byte, err error) *LoadError {
+	loadErr := &LoadError{
+		Path: path,
+		Err:  err,
+		data: data,
+	}
+
+	var offset int64
+	switch err := err.(type) {
+	case *generatedDataError:
+		loadErr.Err = err.err
+		return loadErr
+	case *json.SyntaxError:
+		offset = err.Offset
+	case *json.UnmarshalTypeError:
+		offset = err.Offset
+	default:
+		return loadErr
+	}
+
+	pos := int(offset) - 1
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(data) {
+		pos = len(data)
+	}
+
+	loadErr.Line = bytes.Count(data[:pos], []byte("\n")) + 1
+	loadErr.Column = pos - bytes.LastIndexByte(data[:pos], '\n')
+
+	return loadErr
+}
+
+func (e *LoadError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+//Returns error message followed by source snippet
+//with a caret pointing at the error location.
+func (e *LoadError) Pretty() string {
+	if e.Line == 0 {
+		return e.Error()
+	}
+
+	lines := strings.Split(string(e.data), "\n")
+	first := e.Line - 1
+	if first < 1 {
+		first = 1
+	}
+
+	var b strings.Builder
+	b.WriteString(e.Error())
+	b.WriteString("\n")
+
+	width := len(fmt.Sprint(e.Line))
+	for n := first; n <= e.Line && n <= len(lines); n++ {
+		fmt.Fprintf(&b, "%*d | %s\n", width, n, lines[n-1])
+	}
+
+	caret := []byte{}
+	if e.Line <= len(lines) {
+		line := lines[e.Line-1]
+		for i := 0; i < e.Column-1 && i < len(line); i++ {
+			if line[i] == '\t' {
+				caret = append(caret, '\t')
+			} else {
+				caret = append(caret, ' ')
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%*s | %s^\n", width, "", caret)
+
+	return b.String()
+}
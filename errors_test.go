@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadErrorPretty(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": "{\n  \"a\": 1,\n  \"b\": 2,,\n  \"c\": 3\n}\n"})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+
+	var config map[string]interface{}
+	err := loader.Load(&config)
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("got %v, want LoadError", err)
+	}
+	if loadErr.Line != 3 || loadErr.Column != 10 {
+		t.Errorf("got location %d:%d, want 3:10", loadErr.Line, loadErr.Column)
+	}
+
+	pretty := loadErr.Pretty()
+	if !strings.Contains(pretty, `3 |   "b": 2,,`) {
+		t.Errorf("snippet misses offending line:\n%s", pretty)
+	}
+	if !strings.Contains(pretty, "  |          ^") {
+		t.Errorf("snippet misses caret:\n%s", pretty)
+	}
+}
+
+func TestLoadErrorGeneratedData(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": "{\n  \"a\": \"1\",\n  \"b\": 2,\n  \"c\": true\n}\n"})
+
+	var config struct{ A, B, C int }
+
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	err := loader.Load(&config)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Line != 2 {
+		t.Fatalf("got %v, want error located at line 2 of original data", err)
+	}
+
+	loader = newTestLoader(t, dir, IgnoreMissingFiles|CoerceStringNumbers)
+	err = loader.Load(&config)
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("got %v, want LoadError", err)
+	}
+	if loadErr.Line != 0 || loadErr.Column != 0 {
+		t.Errorf("got location %d:%d of coerced data, want none", loadErr.Line, loadErr.Column)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Value != "bool" {
+		t.Errorf("got %v, want type error of bool value", err)
+	}
+	if pretty := loadErr.Pretty(); strings.Contains(pretty, "^") {
+		t.Errorf("snippet points into unrelated line:\n%s", pretty)
+	}
+}
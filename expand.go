@@ -0,0 +1,150 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//Expands environment variables in data using shell like syntax:
+//${VAR}, ${VAR:-default} and ${VAR:?error message}.
+func expandEnv(data []byte) ([]byte, error) {
+	var err error
+	expanded := expandBraces(string(data), func(expr string) string {
+		value, varErr := expandVar(expr)
+		if varErr != nil && err == nil {
+			err = varErr
+		}
+		return value
+	})
+
+	return []byte(expanded), err
+}
+
+//Returns environment variables referenced as ${VAR} by lookup paths
+//which are currently unset. References with default values
+//are not reported, nor are MessagePack files scanned. Paths denied
+//by BeforeRead are handled like in Load but never reported as skipped.
+func (l *Loader) CheckEnvReferences() ([]string, error) {
+	err := l.createLookupPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	read := readFunc(l.readFile)
+	if l.BeforeRead != nil {
+		read = l.guardRead(read)
+	}
+	seen := map[string]bool{}
+	unset := []string{}
+
+	for _, path := range l.lookupPaths {
+		if isBinaryConfig(path) {
+			continue
+		}
+		data, err := read(context.Background(), path)
+		if err != nil {
+			var denied *deniedError
+			if errors.As(err, &denied) {
+				if l.Implements(FailOnDeniedRead) {
+					return nil, denied.err
+				}
+				continue
+			}
+			if l.Implements(IgnoreMissingFiles) && l.isNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		expandBraces(string(data), func(expr string) string {
+			if strings.Contains(expr, ":-") {
+				return ""
+			}
+			name := expr
+			if i := strings.Index(expr, ":?"); i >= 0 {
+				name = expr[:i]
+			}
+			if !seen[name] && os.Getenv(name) == "" {
+				unset = append(unset, name)
+			}
+			seen[name] = true
+			return ""
+		})
+	}
+
+	sort.Strings(unset)
+	return unset, nil
+}
+
+//Renders data as text/template with TemplateData and TemplateFuncs.
+func (l *Loader) renderTemplate(path string, data []byte) ([]byte, error) {
+	tmpl, err := template.New(filepath.Base(path)).
+		Option("missingkey=error").
+		Funcs(l.TemplateFuncs).
+		Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, l.TemplateData)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func expandVar(expr string) (string, error) {
+	if i := strings.Index(expr, ":-"); i >= 0 {
+		if value := os.Getenv(expr[:i]); value != "" {
+			return value, nil
+		}
+		return expr[i+2:], nil
+	}
+
+	if i := strings.Index(expr, ":?"); i >= 0 {
+		name, message := expr[:i], expr[i+2:]
+		if value := os.Getenv(name); value != "" {
+			return value, nil
+		}
+		if message == "" {
+			message = "parameter null or not set"
+		}
+		return "", fmt.Errorf("%s: %s", name, message)
+	}
+
+	return os.Getenv(expr), nil
+}
+
+//Replaces ${expr} references in s with results of mapping.
+//Unlike os.Expand, bare $name is left untouched, so keys like
+//"$extends" or "$requires" survive expansion.
+func expandBraces(s string, mapping func(expr string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start+2:], '}')
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		b.WriteString(s[:start])
+		b.WriteString(mapping(s[start+2 : end]))
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package conf
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("CONF_TEST_HOST", "db.local")
+	t.Setenv("CONF_TEST_UNSET", "")
+
+	tests := []struct {
+		in, want string
+	}{
+		{`{"host": "${CONF_TEST_HOST}"}`, `{"host": "db.local"}`},
+		{`{"port": ${CONF_TEST_UNSET:-5432}}`, `{"port": 5432}`},
+		{`{"host": "${CONF_TEST_HOST:-other}"}`, `{"host": "db.local"}`},
+		{`{"host": "${CONF_TEST_HOST:?host required}"}`, `{"host": "db.local"}`},
+		{`{"$extends": "base.json", "$requires": ">=1", "cost": "$5"}`, `{"$extends": "base.json", "$requires": ">=1", "cost": "$5"}`},
+	}
+	for _, test := range tests {
+		got, err := expandEnv([]byte(test.in))
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got %s, want %s", test.in, got, test.want)
+		}
+	}
+
+	_, err := expandEnv([]byte(`{"host": "${CONF_TEST_UNSET:?host required}"}`))
+	if err == nil || err.Error() != "CONF_TEST_UNSET: host required" {
+		t.Errorf("got %v, want host required error", err)
+	}
+}
+
+func TestCheckEnvReferences(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"$extends": "base.json", "$requires": ">=1", "host": "${CONF_TEST_HOST}", "port": "${CONF_TEST_PORT:-80}"}`,
+		"config/mixins/test.json": `{"user": "${CONF_TEST_USER:?user required}"}`,
+	})
+	t.Setenv("CONF_TEST_HOST", "db.local")
+	t.Setenv("CONF_TEST_USER", "")
+	loader := newTestLoader(t, dir, UseEnvExpansion)
+
+	unset, err := loader.CheckEnvReferences()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unset) != 1 || unset[0] != "CONF_TEST_USER" {
+		t.Errorf("got %v, want [CONF_TEST_USER]", unset)
+	}
+}
+
+func TestCheckEnvReferencesBeforeRead(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"host": "${CONF_TEST_HOST}"}`,
+		"config/mixins/test.json": `{"secret": "${CONF_TEST_SECRET}"}`,
+	})
+	t.Setenv("CONF_TEST_HOST", "")
+	t.Setenv("CONF_TEST_SECRET", "")
+	mixin := filepath.Join(dir, "config", "mixins", "test.json")
+	denied := errors.New("denied")
+	loader := newTestLoader(t, dir, UseEnvExpansion)
+	loader.BeforeRead = func(path string) error {
+		if path == mixin {
+			return denied
+		}
+		return nil
+	}
+
+	unset, err := loader.CheckEnvReferences()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unset) != 1 || unset[0] != "CONF_TEST_HOST" {
+		t.Errorf("got %v, want [CONF_TEST_HOST]", unset)
+	}
+	if skipped := loader.SkippedPaths(); len(skipped) != 0 {
+		t.Errorf("got skipped %v, want none", skipped)
+	}
+
+	loader = newTestLoader(t, dir, UseEnvExpansion|FailOnDeniedRead)
+	loader.BeforeRead = func(path string) error {
+		if path == mixin {
+			return denied
+		}
+		return nil
+	}
+	_, err = loader.CheckEnvReferences()
+	if err != denied {
+		t.Errorf("got error %v, want %v", err, denied)
+	}
+}
+
+func TestTemplateExpansion(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"endpoint": "api.{{ .region }}.example.com", "name": "{{ upper .name }}"}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles|UseTemplateExpansion)
+	loader.TemplateData = map[string]interface{}{"region": "eu-west-1", "name": "app"}
+	loader.TemplateFuncs = template.FuncMap{"upper": strings.ToUpper}
+
+	var config struct {
+		Endpoint string `json:"endpoint"`
+		Name     string `json:"name"`
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Endpoint != "api.eu-west-1.example.com" || config.Name != "APP" {
+		t.Errorf("got %+v", config)
+	}
+
+	loader.TemplateData = map[string]interface{}{"name": "app"}
+	err = loader.Load(&config)
+	if err == nil || !strings.Contains(err.Error(), `map has no entry for key "region"`) {
+		t.Errorf("got %v, want missing key error", err)
+	}
+}
@@ -0,0 +1,80 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+const extendsKey = "$extends"
+
+//Config file extended by another one with "$extends" key.
+type extendedFile struct {
+	path string
+	//data is the file as read, before env expansion.
+	data []byte
+	//keys are dotted keys of values set by the file itself.
+	keys []string
+}
+
+//Merges values of config file at path over the parent file named by its
+//"$extends" key, recursively. Parent paths are relative to the child.
+//Parents are recorded in doc, the farthest first.
+func (l *Loader) resolveExtends(ctx context.Context, read readFunc, path string, doc *document, seen map[string]bool) error {
+	object, ok := doc.values.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	parentRef, ok := object[extendsKey].(string)
+	if !ok {
+		return nil
+	}
+	delete(object, extendsKey)
+	doc.keys = leafKeys(object, "", nil)
+
+	parentPath := parentRef
+	if !filepath.IsAbs(parentPath) {
+		parentPath = filepath.Join(filepath.Dir(path), parentPath)
+	}
+	parentPath = filepath.Clean(parentPath)
+	if seen[parentPath] {
+		return fmt.Errorf("conf: %s extends cycle through %s", path, parentPath)
+	}
+	seen[parentPath] = true
+
+	rawData, err := read(ctx, parentPath)
+	if err != nil {
+		return err
+	}
+	parentData := rawData
+	if l.Implements(UseEnvExpansion) && !isBinaryConfig(parentPath) {
+		parentData, err = expandEnv(rawData)
+		if err != nil {
+			return err
+		}
+	}
+
+	parent, err := parseDocument(parentPath, parentData)
+	if err != nil {
+		return fmt.Errorf("conf: invalid parent %s: %v", parentPath, err)
+	}
+	parentKeys := leafKeys(parent.values, "", nil)
+	err = l.resolveExtends(ctx, read, parentPath, parent, seen)
+	if err != nil {
+		return err
+	}
+	if parent.parents != nil {
+		parentKeys = parent.keys
+	}
+	doc.parents = append(parent.parents, extendedFile{path: parentPath, data: rawData, keys: parentKeys})
+
+	parentObject, ok := parent.values.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("conf: invalid parent %s: top level value is not an object", parentPath)
+	}
+	mergeValues(parentObject, object, false)
+	doc.values = parentObject
+
+	return doc.reencode()
+}
@@ -0,0 +1,127 @@
+package conf
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type extendsConfig struct {
+	Name string
+	Port int
+	DB   struct {
+		Host string
+		User string
+	}
+}
+
+func TestResolveExtends(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":      `{"$extends": "shared/app.json", "name": "app", "db": {"user": "app"}}`,
+		"shared/app.json":  `{"$extends": "base.json", "port": 8080, "db": {"host": "db"}}`,
+		"shared/base.json": `{"name": "base", "port": 80, "db": {"user": "root"}}`,
+	})
+	loader := newTestLoader(t, dir, ResolveExtends|NoMixins)
+
+	config := extendsConfig{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" || config.Port != 8080 || config.DB.Host != "db" || config.DB.User != "app" {
+		t.Fatalf("got %+v", config)
+	}
+
+	basePath := filepath.Join(dir, "shared", "base.json")
+	appPath := filepath.Join(dir, "shared", "app.json")
+	configPath := filepath.Join(dir, "config.json")
+	want := []string{basePath, appPath, configPath}
+	if !reflect.DeepEqual(loader.LoadedPaths(), want) {
+		t.Errorf("got loaded paths %v, want %v", loader.LoadedPaths(), want)
+	}
+
+	sources := map[string]string{
+		"name":    configPath,
+		"port":    appPath,
+		"db.host": appPath,
+		"db.user": configPath,
+	}
+	if !reflect.DeepEqual(loader.Provenance(), sources) {
+		t.Errorf("got provenance %v, want %v", loader.Provenance(), sources)
+	}
+}
+
+func TestResolveExtendsFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json": `{"$extends": "base.json", "name": "${EXTENDS_TEST_NAME}"}`,
+		"base.json":   `{"port": ${EXTENDS_TEST_PORT}}`,
+	})
+	t.Setenv("EXTENDS_TEST_NAME", "app")
+	t.Setenv("EXTENDS_TEST_PORT", "80")
+	loader := newTestLoader(t, dir, ResolveExtends|UseEnvExpansion|NoMixins)
+
+	config := extendsConfig{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" || config.Port != 80 {
+		t.Fatalf("got %+v", config)
+	}
+	fingerprint := loader.Fingerprint()
+
+	writeFiles(t, dir, map[string]string{"base.json": `{"port": 8080}`})
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 8080 {
+		t.Fatalf("got port %d, want 8080", config.Port)
+	}
+	if loader.Fingerprint() == fingerprint {
+		t.Error("fingerprint did not change with parent file")
+	}
+}
+
+func TestResolveExtendsCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json": `{"$extends": "a.json"}`,
+		"a.json":      `{"$extends": "b.json"}`,
+		"b.json":      `{"$extends": "config.json"}`,
+	})
+	loader := newTestLoader(t, dir, ResolveExtends|NoMixins)
+
+	err := loader.Load(&extendsConfig{})
+	if err == nil || !strings.Contains(err.Error(), "extends cycle") {
+		t.Fatalf("got error %v, want extends cycle", err)
+	}
+
+	loader = newTestLoader(t, dir, ResolveExtends|NoMixins|IgnoreInvalidFiles)
+	err = loader.Load(&extendsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if len(loader.LoadedPaths()) != 0 || loader.SkipReason(configPath) == nil {
+		t.Errorf("got loaded paths %v, want %s skipped", loader.LoadedPaths(), configPath)
+	}
+}
+
+func TestResolveExtendsMissingParent(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json": `{"$extends": "missing.json"}`,
+	})
+	loader := newTestLoader(t, dir, ResolveExtends|NoMixins)
+
+	err := loader.Load(&extendsConfig{})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got error %v, want not exist", err)
+	}
+}
@@ -1,5 +1,11 @@
 module github.com/liquidm/go-conf
 
-go 1.14
+go 1.23
 
-require github.com/kardianos/osext v0.0.0-20190222173326-2bc1f35cddc0
+require (
+	github.com/kardianos/osext v0.0.0-20190222173326-2bc1f35cddc0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
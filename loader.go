@@ -4,13 +4,24 @@ Package conf provides config loader able to load configs using different strateg
 package conf
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
+	"iter"
 	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/kardianos/osext"
 )
@@ -19,15 +30,144 @@ import (
 type Loader struct {
 	//RootPath is a location where loader search for config files.
 	//By default it is set to current working directory.
+	//Leading ~/ or ~user/ is expanded to the home directory.
 	RootPath string
 
 	//Number of arguments that should not be considered as config paths.
 	//It is only used with UseArgumentPaths flag.
 	PreservedArgs int
 
-	lookupPaths  []string
-	loadedPaths  []string
-	skippedPaths []string
+	//ArgumentPathsMode controls UseArgumentPaths flag behaviour when no
+	//arguments follow the PreservedArgs ones. It defaults to ArgFallback.
+	ArgumentPathsMode ArgumentPathsMode
+
+	//ExecSubPath is joined to the executable folder used as RootPath
+	//with UseExecutablePath flag, e.g. "etc" or "../config".
+	//It is used only while RootPath is left as set by NewLoader.
+	ExecSubPath string
+
+	//RootPathEnvVar names environment variable used with UseEnvRootPath flag.
+	//By default it is set to CONFIG_DIR.
+	RootPathEnvVar string
+
+	//CIEnvVar names environment variable used with SkipUserMixinInCI flag.
+	//By default it is set to CI.
+	CIEnvVar string
+
+	//BaseFileNames are probed in order within RootPath and the first
+	//existing one is used as base config file. When none exists the first
+	//name is used. By default it is set to config.json.
+	BaseFileNames []string
+
+	//EnvVar names environment variable holding the active environment.
+	//By default it is set to APP_ENV.
+	EnvVar string
+
+	//NormalizeEnv is applied to the environment read from EnvVar,
+	//e.g. strings.ToLower. By default environment is used as is.
+	NormalizeEnv func(env string) string
+
+	//EnvAliases maps environments, after NormalizeEnv is applied,
+	//to canonical names, e.g. "prod" to "production".
+	EnvAliases map[string]string
+
+	//ArrayEnvFilter maps dotted paths of arrays to the name of an element
+	//field holding environment. Array elements with that field not matching
+	//the active environment are dropped before decoding.
+	ArrayEnvFilter map[string]string
+
+	//IsTestFunc overrides detection of test binaries used with UseTest flag.
+	//By default executables with .test suffix are considered tests.
+	IsTestFunc func() bool
+
+	//FlatOverrides maps top level config keys to values applied after
+	//all config files are loaded. Values are parsed according to the type
+	//of the config field. Unknown keys make Load fail unless
+	//IgnoreInvalidFiles flag is set.
+	FlatOverrides map[string]string
+
+	//RawCapture holds glob patterns of config files which are read
+	//but not decoded. Their contents are available via LoadedData.
+	//Patterns are matched against both full path and file name.
+	RawCapture []string
+
+	//AppVersion is matched against "$requires" version constraint of
+	//config files, e.g. ">=2.0.0". Files not satisfied are skipped.
+	//Constraints are ignored when AppVersion is empty.
+	AppVersion string
+
+	//TemplateData and TemplateFuncs are used with UseTemplateExpansion flag.
+	TemplateData  map[string]interface{}
+	TemplateFuncs template.FuncMap
+
+	//DumpTo receives effective config as indented JSON after each
+	//successful Load. Fields tagged with secret:"true" are redacted.
+	DumpTo io.Writer
+
+	//TimeLayouts are tried in order when decoding strings into time.Time
+	//fields. By default only time.RFC3339 is accepted.
+	TimeLayouts []string
+
+	//OverridePath is always read last, after all other config files.
+	//Load fails when it is missing unless OverrideOptional is set,
+	//in which case IgnoreMissingFiles flag applies.
+	OverridePath     string
+	OverrideOptional bool
+
+	//ReadFileFunc is used to read config files.
+	//By default it is set to ioutil.ReadFile.
+	ReadFileFunc func(path string) ([]byte, error)
+
+	//IsNotFound tells which read errors mean missing config file.
+	//By default it checks for fs.ErrNotExist.
+	IsNotFound func(err error) bool
+
+	//MaxConcurrency limits concurrent reads with ParallelReads flag.
+	//Zero value means no limit.
+	MaxConcurrency int
+
+	//LoadTimeout bounds the whole Load operation.
+	//Load returns context.DeadlineExceeded when it is exceeded.
+	//Zero value means no timeout.
+	LoadTimeout time.Duration
+
+	//Logger receives non fatal problems found during Load.
+	Logger Logger
+
+	//BeforeRead is called for each file before reading it,
+	//checksum sidecars included.
+	//Returned error denies the read and the path is skipped,
+	//or Load fails if FailOnDeniedRead flag is set.
+	BeforeRead func(path string) error
+
+	//OnLoad is called for each config file loaded.
+	OnLoad func(path string)
+
+	//OnSkip is called for each config file skipped with the reason.
+	OnSkip func(path string, reason error)
+
+	//OnComplete is called after each Load with its duration and result.
+	OnComplete func(duration time.Duration, err error)
+
+	lookupPaths   []string
+	lookupOrigins []string
+	fallbacks     []string
+	loadedPaths   []string
+	loadedData    map[string][]byte
+	skippedPaths  []string
+	skipReasons   map[string]error
+	fingerprint   string
+	provenance    map[string]string
+	diagnostics   []error
+	flagWarnings  []error
+	merged        map[string]interface{}
+	label         string
+	lastConfig    interface{}
+	pathMetadata  map[string]map[string]interface{}
+	execFolder    string
+
+	//flagWarningsLogged is set once flagWarnings were passed to Logger.
+	flagWarningsLogged bool
 
 	loaderFlags int
 }
@@ -42,33 +182,196 @@ const (
 
 	//Reads config paths from arguments passed to executable
 	//If number of arguments is not greater than PreservedArgs
-	//it fallbacks to default behaviour.
+	//it fallbacks to default behaviour, see ArgumentPathsMode.
 	UseArgumentPaths int = 1 << iota
 
 	//Use the folder where executable is located as RootPath
 	UseExecutablePath int = 1 << iota
 
-	//Populates SkippedPaths instead of returning error on missing config files
+	//Populates SkippedPaths instead of returning error on missing config files.
+	//Other read errors are still returned, see IsNotFound.
 	IgnoreMissingFiles int = 1 << iota
 
 	//Populates SkippedPaths instead of returning error on invalid JSON files
 	IgnoreInvalidFiles int = 1 << iota
+
+	//Expands environment variables in config files before decoding.
+	//Supports ${VAR}, ${VAR:-default} and ${VAR:?error message} forms,
+	//the latter makes Load fail when VAR is not set.
+	UseEnvExpansion int = 1 << iota
+
+	//Loads only the base config file, skipping all mixins
+	//regardless of other mixin related flags.
+	NoMixins int = 1 << iota
+
+	//Makes later config files only fill keys missing in earlier ones
+	//instead of overriding them.
+	FillMissingOnly int = 1 << iota
+
+	//Sets zero value fields of config struct to value of their
+	//default tag before loading config files.
+	UseDefaultTags int = 1 << iota
+
+	//Verifies each config file against SHA-256 sum stored in
+	//a sidecar file with .sha256 suffix before decoding it.
+	//Missing sidecars are handled like missing config files
+	//and mismatching ones like invalid config files.
+	VerifyChecksums int = 1 << iota
+
+	//Uses all existing BaseFileNames as base config files,
+	//merged in order, instead of the first existing one.
+	MergeBaseFiles int = 1 << iota
+
+	//Makes Load log and record Validate errors in Diagnostics
+	//instead of returning them.
+	ValidateNonFatal int = 1 << iota
+
+	//Uses executable name with .json extension as base config file name,
+	//e.g. worker.json for executable named worker.
+	//Takes precedence over BaseFileNames.
+	UseBinaryNameConfig int = 1 << iota
+
+	//Converts quoted strings to numbers and bools when decoding them
+	//into numeric and bool config fields, e.g. "8080" into int.
+	CoerceStringNumbers int = 1 << iota
+
+	//Uses value of RootPathEnvVar environment variable as RootPath
+	//when it is set. Otherwise RootPath is used as usual.
+	UseEnvRootPath int = 1 << iota
+
+	//Makes Load fail instead of skipping paths denied by BeforeRead.
+	FailOnDeniedRead int = 1 << iota
+
+	//Adds mixin named after the active environment, read from EnvVar,
+	//right after base config files.
+	UseEnvMixin int = 1 << iota
+
+	//Sets config fields tagged with env:"NAME" from NAME environment
+	//variable after config files are loaded. Invalid values of all
+	//variables are reported together before any field is set.
+	UseEnvOverrides int = 1 << iota
+
+	//Decodes config files holding top level JSON array by assigning
+	//its elements to config struct fields in declaration order.
+	PositionalFields int = 1 << iota
+
+	//Renders config files as text/template with TemplateData and
+	//TemplateFuncs before decoding. Templates referencing missing keys
+	//are handled like invalid config files.
+	UseTemplateExpansion int = 1 << iota
+
+	//Makes Load fail on config files with extension other than .json
+	//and without registered decoder, instead of decoding them as JSON.
+	//Files matching RawCapture are never decoded, so they are accepted.
+	StrictExtensions int = 1 << iota
+
+	//Omits user mixin when running in CI, detected by CIEnvVar
+	//environment variable being set.
+	SkipUserMixinInCI int = 1 << iota
+
+	//Reads all lookup paths concurrently, at most MaxConcurrency at
+	//a time, before decoding them in the usual order. BeforeRead is
+	//still called for one path at a time, in order.
+	ParallelReads int = 1 << iota
+
+	//Merges config files over the parent file named by their "$extends"
+	//key, resolved relative to the extending file. Parents are reported
+	//as loaded before the extending file.
+	ResolveExtends int = 1 << iota
+
+	//Makes mixins introducing top level keys absent from base config
+	//files invalid, catching typos in override files. Mixins are invalid
+	//when no base config file was loaded.
+	MixinsOverrideOnly int = 1 << iota
+
+	//Makes NewLoader return error on contradicting flags
+	//instead of reporting them in Diagnostics.
+	StrictFlags int = 1 << iota
 )
 
+//Validator is implemented by configs validating themselves.
+//Load calls Validate once all config files are loaded.
+type Validator interface {
+	Validate() error
+}
+
+//ArgumentPathsMode controls how UseArgumentPaths flag treats arguments.
+//Only arguments after the first PreservedArgs ones are considered config paths.
+type ArgumentPathsMode int
+
+const (
+	//Uses argument paths if there are any, default lookup paths otherwise.
+	ArgFallback ArgumentPathsMode = iota
+
+	//Makes Load fail when there are no argument paths.
+	ArgRequire
+
+	//Never uses argument paths even if UseArgumentPaths flag is set.
+	ArgIgnore
+)
+
+const (
+	//Path was passed as executable argument
+	OriginArgument = "argument"
+
+	//Path is a base config file
+	OriginBase = "base"
+
+	//Path is the test mixin
+	OriginTest = "test"
+
+	//Path is the user mixin
+	OriginUser = "user"
+
+	//Path is the environment mixin
+	OriginEnv = "env"
+
+	//Path is the OverridePath
+	OriginOverride = "override"
+
+	//Path is a name fetched from Source
+	OriginSource = "source"
+
+	//Path is a named pipe read by LoadPipe
+	OriginPipe = "pipe"
+)
+
+//PathInfo describes how a lookup path was resolved.
+type PathInfo struct {
+	//Origin is one of the OriginXXX constants.
+	Origin string
+
+	//Exists tells if the file was present when the path was yielded.
+	Exists bool
+}
+
+var execFolderFunc = osext.ExecutableFolder
+
 //Creates new loader.
 //NewLoader can return error if it fail to identify executable folder
-//and UseExecutablePath flag is set.
+//and UseExecutablePath flag is set, or if flags contradict each other
+//and StrictFlags flag is set. Otherwise contradictions are reported
+//in Diagnostics and passed to Logger by the first Load after it is set.
 func NewLoader(flags int) (*Loader, error) {
 	loader := &Loader{
 		loaderFlags: flags,
 	}
 
+	for _, conflict := range flagConflicts(flags) {
+		if loader.Implements(StrictFlags) {
+			return nil, conflict
+		}
+		loader.flagWarnings = append(loader.flagWarnings, conflict)
+	}
+	loader.diagnostics = loader.flagWarnings
+
 	if loader.Implements(UseExecutablePath) {
-		executableFolder, err := osext.ExecutableFolder()
+		executableFolder, err := execFolderFunc()
 		if err != nil {
 			return nil, err
 		}
 		loader.RootPath = executableFolder
+		loader.execFolder = executableFolder
 	} else {
 		loader.RootPath = "."
 	}
@@ -80,36 +383,554 @@ func NewLoader(flags int) (*Loader, error) {
 //It may return error if config file is missing or invalid and loader
 //has no IgnoreXXX flags set.
 func (l *Loader) Load(config interface{}) error {
-	l.createLookupPaths()
+	return l.loadContext(context.Background(), config)
+}
+
+//Loads config like Load, applying overrides stored in ctx
+//by WithOverrides as the final layer, after env overrides
+//and FlatOverrides.
+func (l *Loader) LoadWithContextOverrides(ctx context.Context, config interface{}) error {
+	return l.loadContext(ctx, config)
+}
+
+func (l *Loader) loadContext(ctx context.Context, config interface{}) error {
+	if l.LoadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.LoadTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := l.load(ctx, config)
+	if l.OnComplete != nil {
+		l.OnComplete(time.Since(start), err)
+	}
+
+	return err
+}
+
+//Loads config like Load, reporting label instead of file paths
+//as the source of loaded values in Provenance.
+//LoadedPaths still reports file paths.
+func (l *Loader) LoadNamed(label string, config interface{}) error {
+	l.label = label
+	defer func() {
+		l.label = ""
+	}()
+
+	return l.Load(config)
+}
+
+//Loads config like Load and returns merged contents of loaded
+//config files, including keys not present in config. Env, flat and
+//context overrides are applied to the returned map as well.
+func (l *Loader) LoadBoth(config interface{}) (map[string]interface{}, error) {
+	err := l.Load(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.merged, nil
+}
+
+//Reloads config from scratch and replaces its contents while holding
+//write lock, so readers holding read lock never see partial config.
+//Config is left untouched when Load fails.
+func (l *Loader) ReloadInPlace(config interface{}, lock *sync.RWMutex) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("conf: ReloadInPlace requires non-nil pointer config")
+	}
+
+	fresh := reflect.New(v.Elem().Type())
+	err := l.Load(fresh.Interface())
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	v.Elem().Set(fresh.Elem())
+	lock.Unlock()
+
+	return nil
+}
+
+//Loads config and, if cond is true, loads other on top of it.
+//Results of other, including provenance, are combined into loader results.
+func (l *Loader) OverlayIf(cond bool, other *Loader, config interface{}) error {
+	err := l.Load(config)
+	if err != nil || !cond {
+		return err
+	}
+
+	err = other.Load(config)
+	if err != nil {
+		return err
+	}
+
+	l.loadedPaths = append(l.loadedPaths, other.loadedPaths...)
+	l.skippedPaths = append(l.skippedPaths, other.skippedPaths...)
+	for path, reason := range other.skipReasons {
+		l.skipReasons[path] = reason
+	}
+	for path, data := range other.loadedData {
+		l.loadedData[path] = data
+	}
+	for key, source := range other.provenance {
+		l.provenance[key] = source
+	}
+	mergeValues(l.merged, other.merged, false)
+
+	return nil
+}
+
+//Loads config using the folder of the calling source file as RootPath.
+//It is meant for tests and defaults bundled with packages, as it relies on
+//source file paths recorded at compile time. In compiled binaries deployed
+//elsewhere that folder usually does not exist.
+func (l *Loader) LoadRelativeToCaller(config interface{}) error {
+	_, file, _, ok := runtime.Caller(1)
+	if !ok {
+		return errors.New("conf: unable to determine caller source file")
+	}
+
+	rootPath := l.RootPath
+	l.RootPath = filepath.Dir(file)
+	defer func() {
+		l.RootPath = rootPath
+	}()
+
+	return l.Load(config)
+}
+
+func (l *Loader) load(ctx context.Context, config interface{}) error {
+	err := l.createLookupPaths()
+	if err != nil {
+		return err
+	}
+
+	return l.loadPaths(ctx, l.readFile, config)
+}
+
+type readFunc func(ctx context.Context, path string) ([]byte, error)
+
+//Loads config from lookupPaths using read.
+//Results of previous call are kept when it fails.
+func (l *Loader) loadPaths(ctx context.Context, read readFunc, config interface{}) (err error) {
+	previous := *l
+	defer func() {
+		if err != nil {
+			l.loadedPaths = previous.loadedPaths
+			l.loadedData = previous.loadedData
+			l.skippedPaths = previous.skippedPaths
+			l.skipReasons = previous.skipReasons
+			l.fingerprint = previous.fingerprint
+			l.provenance = previous.provenance
+			l.diagnostics = previous.diagnostics
+			l.merged = previous.merged
+			l.lastConfig = previous.lastConfig
+		}
+	}()
 
 	l.loadedPaths = []string{}
+	l.loadedData = map[string][]byte{}
 	l.skippedPaths = []string{}
+	l.skipReasons = map[string]error{}
+	l.fingerprint = ""
+	l.provenance = map[string]string{}
+	l.diagnostics = append([]error{}, l.flagWarnings...)
+	if l.Logger != nil && !l.flagWarningsLogged {
+		for _, warning := range l.flagWarnings {
+			l.logDiagnostic(warning)
+		}
+		l.flagWarningsLogged = true
+	}
+	l.merged = nil
+
+	if l.Implements(UseDefaultTags) {
+		v := reflect.ValueOf(config)
+		if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+			err := applyDefaults(v.Elem(), "", l.provenance)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	hash := sha256.New()
+
+	merged := map[string]interface{}{}
+
+	if l.Implements(ParallelReads) {
+		read = l.prefetch(ctx, read)
+	} else if l.BeforeRead != nil {
+		read = l.guardRead(read)
+	}
+
+	//Top level keys of loaded base files, nil until one is loaded.
+	var baseKeys map[string]bool
+
+	for i, configPath := range l.lookupPaths {
+		if l.Implements(StrictExtensions) && !hasDecoder(configPath) && !l.isRawCapture(configPath) {
+			return fmt.Errorf("conf: no decoder for extension of %s", configPath)
+		}
+
+		configData, err := read(ctx, configPath)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if l.lookupOrigins[i] == OriginOverride && !l.OverrideOptional {
+				return err
+			}
+			err := l.readFailure(configPath, err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if l.Implements(VerifyChecksums) {
+			sumData, err := read(ctx, configPath+".sha256")
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				err := l.readFailure(configPath, err)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !checksumMatches(sumData, configData) {
+				err := l.invalidFile(configPath, fmt.Errorf("conf: checksum mismatch for %s", configPath))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
 
-	for _, configPath := range l.lookupPaths {
-		configData, err := ioutil.ReadFile(configPath)
+		rawData := configData
+
+		if l.isRawCapture(configPath) {
+			l.markLoaded(configPath, rawData)
+			hash.Write(rawData)
+			continue
+		}
+
+		if l.Implements(UseEnvExpansion) && !isBinaryConfig(configPath) {
+			configData, err = expandEnv(configData)
+			if err != nil {
+				return &LoadError{Path: configPath, Err: err}
+			}
+		}
+
+		if l.Implements(UseTemplateExpansion) && !isBinaryConfig(configPath) {
+			configData, err = l.renderTemplate(configPath, configData)
+			if err != nil {
+				err := l.invalidFile(configPath, &LoadError{Path: configPath, Err: err})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		doc, err := parseDocument(configPath, configData)
 		if err != nil {
-			if !l.Implements(IgnoreMissingFiles) {
+			err := l.invalidFile(configPath, newLoadError(configPath, configData, err))
+			if err != nil {
 				return err
 			}
-			l.skippedPaths = append(l.skippedPaths, configPath)
 			continue
 		}
 
-		err = json.Unmarshal(configData, config)
+		if l.Implements(ResolveExtends) {
+			seen := map[string]bool{filepath.Clean(configPath): true}
+			err = l.resolveExtends(ctx, read, configPath, doc, seen)
+			if err != nil {
+				err := l.invalidFile(configPath, &LoadError{Path: configPath, Err: err})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if l.AppVersion != "" {
+			ok, err := l.versionMatches(doc.values)
+			if err != nil {
+				err := l.invalidFile(configPath, &LoadError{Path: configPath, Err: err})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if !ok {
+				l.skip(configPath, fmt.Errorf("conf: version mismatch for %s, app version is %s", configPath, l.AppVersion))
+				continue
+			}
+		}
+
+		if l.Implements(MixinsOverrideOnly) && isMixin(l.lookupOrigins[i]) {
+			err := checkMixinKeys(doc.values, baseKeys)
+			if err != nil {
+				err := l.invalidFile(configPath, &LoadError{Path: configPath, Err: err})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		err = l.decode(doc, config, merged)
 		if err != nil {
-			if !l.Implements(IgnoreInvalidFiles) {
+			err := l.invalidFile(configPath, newLoadError(configPath, configData, err))
+			if err != nil {
 				return err
 			}
-			l.skippedPaths = append(l.skippedPaths, configPath)
 			continue
 		}
 
-		l.loadedPaths = append(l.loadedPaths, configPath)
+		for _, parent := range doc.parents {
+			l.markLoaded(parent.path, parent.data)
+			hash.Write(parent.data)
+		}
+		l.markLoaded(configPath, rawData)
+		source := configPath
+		if l.label != "" {
+			source = l.label
+		}
+		l.recordDocument(doc, source)
+		hash.Write(doc.data)
+
+		if l.lookupOrigins[i] == OriginBase {
+			if baseKeys == nil {
+				baseKeys = map[string]bool{}
+			}
+			object, _ := doc.values.(map[string]interface{})
+			for key := range object {
+				baseKeys[key] = true
+			}
+		}
+	}
+
+	for path, field := range l.ArrayEnvFilter {
+		filterArrayEnv(merged, path, field, l.activeEnv())
+	}
+
+	l.merged = merged
+
+	if l.usesMergePath() {
+		err := unmarshalMerged(merged, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	l.fingerprint = hex.EncodeToString(hash.Sum(nil))
+
+	if l.Implements(UseEnvOverrides) {
+		v := reflect.ValueOf(config)
+		if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+			err := l.applyEnvOverrides(v.Elem(), merged)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(l.FlatOverrides) > 0 {
+		err := l.applyFlatOverrides(config, merged)
+		if err != nil {
+			return err
+		}
+	}
+
+	if overrides, ok := ctx.Value(overridesKey{}).(map[string]interface{}); ok {
+		err := l.applyOverrides(overrides, config, merged)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v := reflect.ValueOf(config); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		err := l.checkEnums(v.Elem())
+		if err != nil {
+			return err
+		}
+		err = l.checkValidateTags(v.Elem())
+		if err != nil {
+			return err
+		}
+	}
+
+	if validator, ok := config.(Validator); ok {
+		err := validator.Validate()
+		if err != nil {
+			if !l.Implements(ValidateNonFatal) {
+				return err
+			}
+			l.diagnose(err)
+		}
+	}
+
+	l.lastConfig = config
+
+	if l.DumpTo != nil {
+		data, err := redactedJSON(config)
+		if err != nil {
+			return err
+		}
+		_, err = l.DumpTo.Write(append(data, '\n'))
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+//Records non fatal problem found during Load.
+func (l *Loader) diagnose(err error) {
+	l.diagnostics = append(l.diagnostics, err)
+	if l.Logger != nil {
+		l.logDiagnostic(err)
+	}
+}
+
+func (l *Loader) logDiagnostic(err error) {
+	message := err.Error()
+	if !strings.HasPrefix(message, "conf: ") {
+		message = "conf: " + message
+	}
+	l.Logger.Printf("%s", message)
+}
+
+//Returns non fatal problems found in previous Load call or,
+//before the first one, contradicting flags found by NewLoader.
+func (l *Loader) Diagnostics() []error {
+	return l.diagnostics
+}
+
+func (l *Loader) markLoaded(path string, data []byte) {
+	l.loadedPaths = append(l.loadedPaths, path)
+	l.loadedData[path] = data
+	if l.OnLoad != nil {
+		l.OnLoad(path)
+	}
+}
+
+//Checks if path matches any of RawCapture patterns.
+func (l *Loader) isRawCapture(path string) bool {
+	for _, pattern := range l.RawCapture {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//Handles error reading path. Returns error aborting Load
+//or nil when path was skipped.
+func (l *Loader) readFailure(path string, err error) error {
+	var denied *deniedError
+	if errors.As(err, &denied) {
+		if l.Implements(FailOnDeniedRead) {
+			return denied.err
+		}
+		l.skip(path, denied.err)
+		return nil
+	}
+
+	if !l.Implements(IgnoreMissingFiles) || !l.isNotFound(err) {
+		return err
+	}
+	l.skip(path, err)
+	return nil
+}
+
+//Handles invalid config file at path. Returns error aborting Load
+//or nil when path was skipped due to IgnoreInvalidFiles flag.
+func (l *Loader) invalidFile(path string, err error) error {
+	if !l.Implements(IgnoreInvalidFiles) {
+		return err
+	}
+	l.skip(path, err)
+	return nil
+}
+
+func (l *Loader) skip(path string, reason error) {
+	l.skippedPaths = append(l.skippedPaths, path)
+	l.skipReasons[path] = reason
+	if l.OnSkip != nil {
+		l.OnSkip(path, reason)
+	}
+}
+
+//Returns error if mixin with given name does not exist
+//within mixins folder of RootPath.
+func (l *Loader) RequireMixin(name string) error {
+	_, err := os.Stat(l.mixinPath(name))
+	return err
+}
+
+func (l *Loader) isNotFound(err error) bool {
+	if l.IsNotFound != nil {
+		return l.IsNotFound(err)
+	}
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+func (l *Loader) readFileFunc() func(path string) ([]byte, error) {
+	if l.ReadFileFunc != nil {
+		return l.ReadFileFunc
+	}
+	return ioutil.ReadFile
+}
+
+func (l *Loader) readFile(ctx context.Context, path string) ([]byte, error) {
+	readFile := l.readFileFunc()
+
+	if ctx.Done() == nil {
+		return readFile(path)
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		data, err := readFile(path)
+		done <- result{data, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//Checks data against sha256sum formatted sidecar contents.
+func checksumMatches(sumData, data []byte) bool {
+	fields := strings.Fields(string(sumData))
+	if len(fields) == 0 {
+		return false
+	}
+
+	sum := sha256.Sum256(data)
+	return strings.EqualFold(fields[0], hex.EncodeToString(sum[:]))
+}
+
 //Checks if loader has flag set.
 func (l *Loader) Implements(behaviour int) bool {
 	return l.loaderFlags&behaviour > 0
@@ -120,37 +941,283 @@ func (l *Loader) LoadedPaths() []string {
 	return l.loadedPaths
 }
 
+//Returns contents of config files loaded in previous Load call
+//keyed by their paths.
+func (l *Loader) LoadedData() map[string][]byte {
+	return l.loadedData
+}
+
 //Returns config files skipped in previous Load call.
 func (l *Loader) SkippedPaths() []string {
 	return l.skippedPaths
 }
 
-func (l *Loader) createLookupPaths() {
-	if l.Implements(UseArgumentPaths) {
+//Attaches metadata to config file path, e.g. to mark it trusted
+//for downstream policy decisions. Metadata is kept across Load calls.
+func (l *Loader) WithPathMetadata(path string, meta map[string]interface{}) {
+	if l.pathMetadata == nil {
+		l.pathMetadata = map[string]map[string]interface{}{}
+	}
+	l.pathMetadata[path] = meta
+}
+
+//Returns metadata attached to path with WithPathMetadata.
+func (l *Loader) PathMetadata(path string) map[string]interface{} {
+	return l.pathMetadata[path]
+}
+
+//Returns reason why path was skipped in previous Load call
+//or nil if it was not skipped.
+func (l *Loader) SkipReason(path string) error {
+	return l.skipReasons[path]
+}
+
+//Returns SHA-256 of config files contents loaded in previous Load call.
+func (l *Loader) Fingerprint() string {
+	return l.fingerprint
+}
+
+//Returns reasons why flags fell back to default behaviour
+//when lookup paths were last resolved.
+func (l *Loader) Fallbacks() []string {
+	return l.fallbacks
+}
+
+//Returns config files resolved for previous Load call or,
+//if paths are not resolved yet, the ones the next Load call would read.
+func (l *Loader) LookupPaths() []string {
+	if l.lookupPaths == nil {
+		l.createLookupPaths()
+	}
+	return l.lookupPaths
+}
+
+//Clears resolved lookup paths so they are resolved again, e.g. after
+//RootPath changes. Results of previous Load call are kept until
+//the next successful one.
+func (l *Loader) InvalidatePaths() {
+	l.lookupPaths = nil
+	l.lookupOrigins = nil
+	l.fallbacks = nil
+}
+
+//Returns an iterator over the paths LookupPaths would return,
+//yielding each path together with its origin and existence.
+func (l *Loader) PathsSeq() iter.Seq2[string, PathInfo] {
+	if l.lookupPaths == nil {
+		l.createLookupPaths()
+	}
+	paths, origins := l.lookupPaths, l.lookupOrigins
+
+	return func(yield func(string, PathInfo) bool) {
+		for i, path := range paths {
+			_, err := os.Stat(path)
+			if !yield(path, PathInfo{Origin: origins[i], Exists: err == nil}) {
+				return
+			}
+		}
+	}
+}
+
+func (l *Loader) createLookupPaths() error {
+	l.lookupPaths = nil
+	l.lookupOrigins = nil
+	l.fallbacks = nil
+
+	err := l.addDefaultLookupPaths()
+	if err != nil {
+		return err
+	}
+
+	if l.OverridePath != "" {
+		l.addLookupPath(l.OverridePath, OriginOverride)
+	}
+
+	return nil
+}
+
+func (l *Loader) addDefaultLookupPaths() error {
+	if l.Implements(UseArgumentPaths) && l.ArgumentPathsMode != ArgIgnore {
 		splitSize := l.PreservedArgs + 1
 		if len(os.Args) > splitSize {
-			l.lookupPaths = os.Args[splitSize:]
-			return
+			for _, path := range os.Args[splitSize:] {
+				l.addLookupPath(path, OriginArgument)
+			}
+			return nil
+		}
+		if l.ArgumentPathsMode == ArgRequire {
+			return fmt.Errorf("conf: no config paths given after %d preserved arguments", l.PreservedArgs)
 		}
 	}
 
-	l.lookupPaths = []string{
-		filepath.Join(l.RootPath, "config.json"),
+	if l.Implements(UseArgumentPaths) {
+		if l.ArgumentPathsMode == ArgIgnore {
+			l.fallbacks = append(l.fallbacks, "UseArgumentPaths: ignored by ArgIgnore mode, using defaults")
+		} else {
+			l.fallbacks = append(l.fallbacks, "UseArgumentPaths: no arguments, using defaults")
+		}
+	}
+	if l.Implements(UseEnvRootPath) && os.Getenv(l.rootPathEnvVar()) == "" {
+		l.fallbacks = append(l.fallbacks, fmt.Sprintf("UseEnvRootPath: %s not set, using RootPath", l.rootPathEnvVar()))
+	}
+
+	for _, path := range l.basePaths() {
+		l.addLookupPath(path, OriginBase)
+	}
+
+	if l.Implements(NoMixins) {
+		return nil
+	}
+
+	if l.Implements(UseEnvMixin) {
+		env := l.activeEnv()
+		if len(env) > 0 {
+			l.addLookupPath(l.mixinPath(env), OriginEnv)
+		}
 	}
 
-	if l.Implements(UseTest) && l.isTest() {
-		l.lookupPaths = append(l.lookupPaths, filepath.Join(l.RootPath, "config", "mixins", "test.json"))
+	if l.IsTestMode() {
+		l.addLookupPath(l.mixinPath("test"), OriginTest)
 	} else {
 		user := l.user()
-		if len(user) > 0 {
-			l.lookupPaths = append(l.lookupPaths, filepath.Join(l.RootPath, "config", "mixins", fmt.Sprintf("%s.json", user)))
+		if len(user) > 0 && !l.skipUserMixin() {
+			l.addLookupPath(l.mixinPath(user), OriginUser)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) basePaths() []string {
+	names := l.BaseFileNames
+	if l.Implements(UseBinaryNameConfig) {
+		names = []string{binaryName() + ".json"}
+	}
+	if len(names) == 0 {
+		names = []string{"config.json"}
+	}
+
+	paths := []string{}
+	for _, name := range names {
+		path := filepath.Join(l.rootPath(), name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if !l.Implements(MergeBaseFiles) {
+			return []string{path}
+		}
+		paths = append(paths, path)
+	}
+
+	if len(paths) == 0 {
+		paths = append(paths, filepath.Join(l.rootPath(), names[0]))
+	}
+
+	return paths
+}
+
+func (l *Loader) activeEnv() string {
+	envVar := l.EnvVar
+	if envVar == "" {
+		envVar = "APP_ENV"
+	}
+	env := os.Getenv(envVar)
+
+	if l.NormalizeEnv != nil {
+		env = l.NormalizeEnv(env)
+	}
+	if alias, ok := l.EnvAliases[env]; ok {
+		env = alias
+	}
+
+	return env
+}
+
+//Returns executable name without extension and test suffix.
+func binaryName() string {
+	name := filepath.Base(os.Args[0])
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.TrimSuffix(name, ".test")
+}
+
+func (l *Loader) rootPathEnvVar() string {
+	if l.RootPathEnvVar == "" {
+		return "CONFIG_DIR"
+	}
+	return l.RootPathEnvVar
+}
+
+func (l *Loader) rootPath() string {
+	if l.Implements(UseEnvRootPath) {
+		if dir := os.Getenv(l.rootPathEnvVar()); dir != "" {
+			return expandHome(dir)
 		}
 	}
+
+	if l.ExecSubPath != "" && l.execFolder != "" && l.RootPath == l.execFolder {
+		return filepath.Join(l.execFolder, l.ExecSubPath)
+	}
+
+	return expandHome(l.RootPath)
+}
+
+//Expands leading ~ or ~user in path to the home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, "/"+string(filepath.Separator)); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+
+	var home string
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		home = dir
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return path
+		}
+		home = u.HomeDir
+	}
+
+	return filepath.Join(home, rest)
+}
+
+func isMixin(origin string) bool {
+	return origin == OriginTest || origin == OriginUser || origin == OriginEnv
+}
+
+func (l *Loader) mixinPath(name string) string {
+	return filepath.Join(l.rootPath(), "config", "mixins", fmt.Sprintf("%s.json", name))
+}
+
+func (l *Loader) addLookupPath(path, origin string) {
+	l.lookupPaths = append(l.lookupPaths, path)
+	l.lookupOrigins = append(l.lookupOrigins, origin)
+}
+
+func (l *Loader) skipUserMixin() bool {
+	if !l.Implements(SkipUserMixinInCI) {
+		return false
+	}
+
+	envVar := l.CIEnvVar
+	if envVar == "" {
+		envVar = "CI"
+	}
+	return os.Getenv(envVar) != ""
 }
 
 func (l *Loader) user() string {
 	if l.Implements(UseDotUser) {
-		fileContents, err := ioutil.ReadFile(filepath.Join(l.RootPath, ".user"))
+		fileContents, err := ioutil.ReadFile(filepath.Join(l.rootPath(), ".user"))
 		if err == nil {
 			return strings.TrimSpace(string(fileContents))
 		}
@@ -164,7 +1231,15 @@ func (l *Loader) user() string {
 	return user.Username
 }
 
+//Checks if test mixin is used, that is UseTest flag is set
+//and the executable is a test binary.
+func (l *Loader) IsTestMode() bool {
+	return l.Implements(UseTest) && l.isTest()
+}
+
 func (l *Loader) isTest() bool {
-	runfile := os.Args[0]
-	return runfile[len(runfile)-5:] == ".test"
+	if l.IsTestFunc != nil {
+		return l.IsTestFunc()
+	}
+	return strings.HasSuffix(os.Args[0], ".test")
 }
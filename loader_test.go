@@ -0,0 +1,788 @@
+package conf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//Creates loader rooted at dir which treats the binary as a test
+//binary, so lookup paths do not depend on the current user.
+func newTestLoader(t *testing.T, dir string, flags int) *Loader {
+	t.Helper()
+	loader, err := NewLoader(flags | UseTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.RootPath = dir
+	loader.IsTestFunc = func() bool { return true }
+	return loader
+}
+
+func TestPathsSeq(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{}`})
+	loader := newTestLoader(t, dir, 0)
+
+	paths := []string{}
+	infos := []PathInfo{}
+	for path, info := range loader.PathsSeq() {
+		paths = append(paths, path)
+		infos = append(infos, info)
+	}
+
+	want := []string{
+		filepath.Join(dir, "config.json"),
+		filepath.Join(dir, "config", "mixins", "test.json"),
+	}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("got paths %v, want %v", paths, want)
+	}
+	if infos[0] != (PathInfo{Origin: OriginBase, Exists: true}) {
+		t.Errorf("got base info %+v", infos[0])
+	}
+	if infos[1] != (PathInfo{Origin: OriginTest, Exists: false}) {
+		t.Errorf("got test mixin info %+v", infos[1])
+	}
+
+	for range loader.PathsSeq() {
+		break
+	}
+}
+
+func TestLoadTimeout(t *testing.T) {
+	loader := newTestLoader(t, t.TempDir(), 0)
+	loader.LoadTimeout = 10 * time.Millisecond
+	loader.ReadFileFunc = func(path string) ([]byte, error) {
+		time.Sleep(200 * time.Millisecond)
+		return []byte(`{}`), nil
+	}
+
+	var config map[string]interface{}
+	err := loader.Load(&config)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want deadline exceeded", err)
+	}
+}
+
+func TestRequireMixin(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config/mixins/production.json": `{}`})
+	loader := newTestLoader(t, dir, 0)
+
+	err := loader.RequireMixin("production")
+	if err != nil {
+		t.Errorf("present mixin: %v", err)
+	}
+
+	err = loader.RequireMixin("staging")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("absent mixin: got %v, want not exist error", err)
+	}
+}
+
+func TestLoadRelativeToCaller(t *testing.T) {
+	loader := newTestLoader(t, "/nonexistent", IgnoreMissingFiles)
+	loader.BaseFileNames = []string{filepath.Join("testdata", "caller.json")}
+
+	var config struct {
+		Name string `json:"name"`
+	}
+	err := loader.LoadRelativeToCaller(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "caller" {
+		t.Errorf("got name %q, want caller", config.Name)
+	}
+	if loader.RootPath != "/nonexistent" {
+		t.Errorf("RootPath not restored, got %s", loader.RootPath)
+	}
+}
+
+func TestNoMixins(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".user":                    "alice",
+		"config/mixins/test.json":  `{}`,
+		"config/mixins/alice.json": `{}`,
+	})
+	loader := newTestLoader(t, dir, UseDotUser|NoMixins)
+
+	paths := loader.LookupPaths()
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "config.json") {
+		t.Errorf("got %v, want only base path", paths)
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	data := `{"a": 1}`
+	sum := sha256.Sum256([]byte(data))
+	valid := hex.EncodeToString(sum[:]) + "  config.json\n"
+	invalid := strings.Repeat("0", 64) + "  config.json\n"
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		flags   int
+		wantErr bool
+		want    int
+	}{
+		{"matching", map[string]string{"config.json": data, "config.json.sha256": valid}, 0, false, 1},
+		{"mismatching", map[string]string{"config.json": data, "config.json.sha256": invalid}, 0, true, 0},
+		{"mismatching ignored", map[string]string{"config.json": data, "config.json.sha256": invalid}, IgnoreInvalidFiles, false, 0},
+		{"missing", map[string]string{"config.json": data}, 0, true, 0},
+		{"missing ignored", map[string]string{"config.json": data}, IgnoreMissingFiles, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, test.files)
+			loader := newTestLoader(t, dir, VerifyChecksums|NoMixins|test.flags)
+
+			var config struct{ A int }
+			err := loader.Load(&config)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if config.A != test.want {
+				t.Errorf("got a=%d, want %d", config.A, test.want)
+			}
+		})
+	}
+}
+
+func TestRootPathHomeExpansion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	loader := newTestLoader(t, "~/cfg", NoMixins)
+
+	paths := loader.LookupPaths()
+	if want := filepath.Join(home, "cfg", "config.json"); len(paths) != 1 || paths[0] != want {
+		t.Errorf("got %v, want [%s]", paths, want)
+	}
+}
+
+func TestBaseFileNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.json": `{"name": "app"}`})
+	loader := newTestLoader(t, dir, NoMixins)
+	loader.BaseFileNames = []string{"config.json", "app.json"}
+
+	var config struct{ Name string }
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" {
+		t.Errorf("got name %q, want app", config.Name)
+	}
+
+	loader = newTestLoader(t, t.TempDir(), NoMixins|IgnoreMissingFiles)
+	loader.BaseFileNames = []string{"config.json", "app.json"}
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped := loader.SkippedPaths(); len(skipped) != 1 || filepath.Base(skipped[0]) != "config.json" {
+		t.Errorf("got skipped %v, want first base name", skipped)
+	}
+}
+
+type validatedConfig struct {
+	Port int
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Port == 0 {
+		return errors.New("port is required")
+	}
+	return nil
+}
+
+func TestValidateNonFatal(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{}`})
+
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	err := loader.Load(&validatedConfig{})
+	if err == nil || err.Error() != "port is required" {
+		t.Errorf("got %v, want validation error", err)
+	}
+
+	logger := &captureLogger{}
+	loader = newTestLoader(t, dir, IgnoreMissingFiles|ValidateNonFatal)
+	loader.Logger = logger
+	err = loader.Load(&validatedConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics := loader.Diagnostics(); len(diagnostics) != 1 || diagnostics[0].Error() != "port is required" {
+		t.Errorf("got diagnostics %v", diagnostics)
+	}
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "port is required") {
+		t.Errorf("got log %v", logger.lines)
+	}
+}
+
+func TestExecSubPath(t *testing.T) {
+	execFolder := t.TempDir()
+	writeFiles(t, execFolder, map[string]string{"etc/config.json": `{}`})
+
+	previous := execFolderFunc
+	execFolderFunc = func() (string, error) { return execFolder, nil }
+	defer func() { execFolderFunc = previous }()
+
+	loader, err := NewLoader(UseExecutablePath | NoMixins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.ExecSubPath = "etc"
+
+	paths := loader.LookupPaths()
+	if want := filepath.Join(execFolder, "etc", "config.json"); len(paths) != 1 || paths[0] != want {
+		t.Errorf("got paths %v, want %s", paths, want)
+	}
+	if loader.RootPath != execFolder {
+		t.Errorf("got RootPath %s, want it left as %s", loader.RootPath, execFolder)
+	}
+
+	loader.ExecSubPath = "conf"
+	loader.InvalidatePaths()
+	paths = loader.LookupPaths()
+	if want := filepath.Join(execFolder, "conf", "config.json"); len(paths) != 1 || paths[0] != want {
+		t.Errorf("got paths %v after changing ExecSubPath, want %s", paths, want)
+	}
+
+	loader, err = NewLoader(UseExecutablePath | NoMixins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.ExecSubPath = "etc"
+	loader.RootPath = "/srv/app"
+	if paths := loader.LookupPaths(); paths[0] != filepath.Join("/srv/app", "config.json") {
+		t.Errorf("ExecSubPath applied to RootPath set by caller: %v", paths)
+	}
+}
+
+func TestLoadBoth(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"port": 80, "extra": {"a": 1}}`,
+		"config/mixins/test.json": `{"extra": {"b": 2}}`,
+	})
+	loader := newTestLoader(t, dir, 0)
+
+	var config struct {
+		Port int `json:"port"`
+	}
+	merged, err := loader.LoadBoth(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Port != 80 {
+		t.Errorf("got port %d, want 80", config.Port)
+	}
+	extra, ok := merged["extra"].(map[string]interface{})
+	if !ok || extra["a"] != 1.0 || extra["b"] != 2.0 {
+		t.Errorf("got merged %v, want unknown extra keys of both files", merged)
+	}
+}
+
+func TestLoadBothOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"port": 1, "db": {"host": "base"}}`})
+	t.Setenv("LOAD_BOTH_TEST_HOST", "env")
+	loader := newTestLoader(t, dir, NoMixins|UseEnvOverrides)
+	loader.FlatOverrides = map[string]string{"port": "2"}
+
+	var config struct {
+		Port int `json:"port"`
+		Db   struct {
+			Host string `json:"host" env:"LOAD_BOTH_TEST_HOST"`
+		} `json:"db"`
+	}
+	merged, err := loader.LoadBoth(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Port != 2 || config.Db.Host != "env" {
+		t.Errorf("got %+v", config)
+	}
+	db, _ := merged["db"].(map[string]interface{})
+	if merged["port"] != 2.0 || db["host"] != "env" {
+		t.Errorf("got merged %v, want overridden port and db.host", merged)
+	}
+}
+
+func TestUseBinaryNameConfig(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	for _, name := range []string{"worker", "/usr/bin/worker.exe", "worker.test"} {
+		os.Args = []string{name}
+		loader := newTestLoader(t, "/etc/app", UseBinaryNameConfig|NoMixins)
+		loader.BaseFileNames = []string{"config.json"}
+
+		paths := loader.LookupPaths()
+		if want := filepath.Join("/etc/app", "worker.json"); len(paths) != 1 || paths[0] != want {
+			t.Errorf("%s: got %v, want [%s]", name, paths, want)
+		}
+	}
+}
+
+func TestIsTestMode(t *testing.T) {
+	loader, err := NewLoader(UseTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loader.IsTestMode() {
+		t.Error("test binary not detected")
+	}
+
+	loader.IsTestFunc = func() bool { return false }
+	if loader.IsTestMode() {
+		t.Error("IsTestFunc not used")
+	}
+
+	loader, err = NewLoader(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loader.IsTestMode() {
+		t.Error("test mode without UseTest flag")
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	errNoSuchKey := errors.New("NoSuchKey")
+	errDenied := errors.New("AccessDenied")
+
+	loader := newTestLoader(t, "/bucket", IgnoreMissingFiles)
+	loader.ReadFileFunc = func(path string) ([]byte, error) {
+		if filepath.Base(path) == "config.json" {
+			return []byte(`{"a": 1}`), nil
+		}
+		return nil, errNoSuchKey
+	}
+	loader.IsNotFound = func(err error) bool {
+		return errors.Is(err, errNoSuchKey)
+	}
+
+	var config struct{ A int }
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped := loader.SkippedPaths(); len(skipped) != 1 || !errors.Is(loader.SkipReason(skipped[0]), errNoSuchKey) {
+		t.Errorf("got skipped %v, want test mixin skipped as missing", skipped)
+	}
+
+	loader.ReadFileFunc = func(path string) ([]byte, error) {
+		return nil, errDenied
+	}
+	err = loader.Load(&config)
+	if !errors.Is(err, errDenied) {
+		t.Errorf("got %v, want error not recognised as missing file", err)
+	}
+}
+
+func TestOverlayIf(t *testing.T) {
+	global, regional := t.TempDir(), t.TempDir()
+	writeFiles(t, global, map[string]string{"config.json": `{"host": "global", "port": 80}`})
+	writeFiles(t, regional, map[string]string{"config.json": `{"host": "eu"}`})
+
+	type config struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+
+	for _, cond := range []bool{false, true} {
+		loader := newTestLoader(t, global, IgnoreMissingFiles)
+		other := newTestLoader(t, regional, IgnoreMissingFiles)
+
+		var c config
+		err := loader.OverlayIf(cond, other, &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantHost, wantSource := "global", filepath.Join(global, "config.json")
+		if cond {
+			wantHost, wantSource = "eu", filepath.Join(regional, "config.json")
+		}
+		if c.Host != wantHost || c.Port != 80 {
+			t.Errorf("cond %v: got %+v", cond, c)
+		}
+		if source, _ := loader.SourceOf("host"); source != wantSource {
+			t.Errorf("cond %v: got host source %s, want %s", cond, source, wantSource)
+		}
+		if source, _ := loader.SourceOf("port"); source != filepath.Join(global, "config.json") {
+			t.Errorf("cond %v: got port source %s", cond, source)
+		}
+	}
+}
+
+func TestRawCapture(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"name": "base"}`,
+		"config/mixins/test.json": `{{ template }}`,
+	})
+	loader := newTestLoader(t, dir, 0)
+	loader.RawCapture = []string{"test.json"}
+
+	var config struct{ Name string }
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mixin := filepath.Join(dir, "config", "mixins", "test.json")
+	if config.Name != "base" {
+		t.Errorf("got name %q, want base", config.Name)
+	}
+	if data := loader.LoadedData()[mixin]; string(data) != `{{ template }}` {
+		t.Errorf("got captured %q", data)
+	}
+	if _, ok := loader.SourceOf("name"); !ok || len(loader.Provenance()) != 1 {
+		t.Errorf("captured file affected provenance: %v", loader.Provenance())
+	}
+}
+
+func TestRawCaptureStrictExtensions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json": `{"name": "base"}`,
+		"banner.tmpl": `Welcome to {{ .name }}`,
+	})
+	loader := newTestLoader(t, dir, NoMixins|MergeBaseFiles|StrictExtensions)
+	loader.BaseFileNames = []string{"config.json", "banner.tmpl"}
+	loader.RawCapture = []string{"*.tmpl"}
+
+	var config struct{ Name string }
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := loader.LoadedData()[filepath.Join(dir, "banner.tmpl")]; string(data) != `Welcome to {{ .name }}` {
+		t.Errorf("got captured %q", data)
+	}
+}
+
+func TestUseEnvRootPath(t *testing.T) {
+	dir := t.TempDir()
+	loader := newTestLoader(t, "/etc/app", UseEnvRootPath|NoMixins)
+	loader.RootPathEnvVar = "CONF_TEST_DIR"
+
+	t.Setenv("CONF_TEST_DIR", dir)
+	if paths := loader.LookupPaths(); paths[0] != filepath.Join(dir, "config.json") {
+		t.Errorf("env set: got %v", paths)
+	}
+
+	t.Setenv("CONF_TEST_DIR", "")
+	loader.InvalidatePaths()
+	if paths := loader.LookupPaths(); paths[0] != filepath.Join("/etc/app", "config.json") {
+		t.Errorf("env unset: got %v", paths)
+	}
+	if fallbacks := loader.Fallbacks(); len(fallbacks) != 1 || !strings.Contains(fallbacks[0], "CONF_TEST_DIR not set") {
+		t.Errorf("got fallbacks %v", fallbacks)
+	}
+}
+
+func TestReloadInPlace(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"hosts": ["a", "b"], "port": 1}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+
+	type config struct {
+		Hosts []string `json:"hosts"`
+		Port  int      `json:"port"`
+	}
+	var c config
+	var lock sync.RWMutex
+	err := loader.ReloadInPlace(&c, &lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				lock.RLock()
+				if len(c.Hosts) != 2 || c.Port == 0 {
+					t.Errorf("reader saw partial config %+v", c)
+				}
+				lock.RUnlock()
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		err := loader.ReloadInPlace(&c, &lock)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	writeFiles(t, dir, map[string]string{"config.json": `{`})
+	if loader.ReloadInPlace(&c, &lock) == nil {
+		t.Error("invalid config reloaded")
+	}
+	close(done)
+	wg.Wait()
+
+	if c.Port != 1 {
+		t.Errorf("failed reload changed config: %+v", c)
+	}
+}
+
+func TestArgumentPathsMode(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	base := filepath.Join("/etc/app", "config.json")
+	tests := []struct {
+		mode    ArgumentPathsMode
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{ArgFallback, []string{"app", "serve", "a.json"}, []string{"a.json"}, false},
+		{ArgFallback, []string{"app", "serve"}, []string{base}, false},
+		{ArgRequire, []string{"app", "serve", "a.json", "b.json"}, []string{"a.json", "b.json"}, false},
+		{ArgRequire, []string{"app", "serve"}, nil, true},
+		{ArgIgnore, []string{"app", "serve", "a.json"}, []string{base}, false},
+	}
+	for _, test := range tests {
+		os.Args = test.args
+		loader := newTestLoader(t, "/etc/app", UseArgumentPaths|NoMixins)
+		loader.PreservedArgs = 1
+		loader.ArgumentPathsMode = test.mode
+
+		err := loader.createLookupPaths()
+		if (err != nil) != test.wantErr {
+			t.Errorf("mode %d, args %v: got error %v", test.mode, test.args, err)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(loader.lookupPaths, test.want) {
+			t.Errorf("mode %d, args %v: got %v, want %v", test.mode, test.args, loader.lookupPaths, test.want)
+		}
+	}
+}
+
+func TestEnvAliases(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config/mixins/production.json": `{"name": "production"}`})
+
+	for _, env := range []string{"prod", "PRODUCTION"} {
+		t.Setenv("APP_ENV", env)
+		loader := newTestLoader(t, dir, UseEnvMixin|IgnoreMissingFiles)
+		loader.NormalizeEnv = strings.ToLower
+		loader.EnvAliases = map[string]string{"prod": "production"}
+
+		var config struct{ Name string }
+		err := loader.Load(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Name != "production" {
+			t.Errorf("%s: got %q, want production mixin", env, config.Name)
+		}
+	}
+}
+
+func TestSkipUserMixinInCI(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{".user": "jenkins", "config/mixins/jenkins.json": `{}`})
+	userMixin := filepath.Join(dir, "config", "mixins", "jenkins.json")
+
+	loader, err := NewLoader(UseDotUser | SkipUserMixinInCI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.RootPath = dir
+
+	t.Setenv("CI", "")
+	if paths := loader.LookupPaths(); len(paths) != 2 || paths[1] != userMixin {
+		t.Errorf("outside CI: got %v, want user mixin", paths)
+	}
+
+	t.Setenv("CI", "true")
+	loader.InvalidatePaths()
+	if paths := loader.LookupPaths(); len(paths) != 1 {
+		t.Errorf("in CI: got %v, want user mixin omitted", paths)
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app"}
+
+	loader := newTestLoader(t, "/etc/app", UseArgumentPaths)
+	loader.LookupPaths()
+
+	want := []string{"UseArgumentPaths: no arguments, using defaults"}
+	if !reflect.DeepEqual(loader.Fallbacks(), want) {
+		t.Errorf("got %v, want %v", loader.Fallbacks(), want)
+	}
+}
+
+func TestInvalidatePaths(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	writeFiles(t, first, map[string]string{"config.json": `{"name": "first"}`})
+	writeFiles(t, second, map[string]string{"config.json": `{"name": `})
+	loader := newTestLoader(t, first, NoMixins)
+
+	config := struct{ Name string }{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstPath := filepath.Join(first, "config.json")
+	fingerprint := loader.Fingerprint()
+
+	loader.RootPath = second
+	if paths := loader.LookupPaths(); len(paths) != 1 || paths[0] != firstPath {
+		t.Fatalf("got lookup paths %v before invalidation, want %s", paths, firstPath)
+	}
+
+	loader.InvalidatePaths()
+	secondPath := filepath.Join(second, "config.json")
+	if paths := loader.LookupPaths(); len(paths) != 1 || paths[0] != secondPath {
+		t.Fatalf("got lookup paths %v after invalidation, want %s", paths, secondPath)
+	}
+	if paths := loader.LoadedPaths(); len(paths) != 1 || paths[0] != firstPath {
+		t.Errorf("got loaded paths %v after invalidation, want %s", paths, firstPath)
+	}
+
+	err = loader.Load(&config)
+	if err == nil {
+		t.Fatal("expected error loading invalid config")
+	}
+	if paths := loader.LoadedPaths(); len(paths) != 1 || paths[0] != firstPath {
+		t.Errorf("got loaded paths %v after failed Load, want %s", paths, firstPath)
+	}
+	if loader.Fingerprint() != fingerprint {
+		t.Error("fingerprint changed after failed Load")
+	}
+
+	writeFiles(t, second, map[string]string{"config.json": `{"name": "second"}`})
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths := loader.LoadedPaths(); config.Name != "second" || len(paths) != 1 || paths[0] != secondPath {
+		t.Errorf("got %q from %v, want second from %s", config.Name, paths, secondPath)
+	}
+}
+
+func TestOverridePath(t *testing.T) {
+	dir := t.TempDir()
+	overridePath := filepath.Join(t.TempDir(), "override.json")
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"host": "base", "port": 1}`,
+		"config/mixins/test.json": `{"host": "mixin"}`,
+	})
+	err := os.WriteFile(overridePath, []byte(`{"host": "override"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	loader.OverridePath = overridePath
+
+	var config struct {
+		Host string
+		Port int
+	}
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "override" || config.Port != 1 {
+		t.Errorf("got %+v", config)
+	}
+	if paths := loader.LoadedPaths(); paths[len(paths)-1] != overridePath {
+		t.Errorf("got loaded paths %v, want %s last", paths, overridePath)
+	}
+
+	err = os.Remove(overridePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = loader.Load(&config)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got error %v, want missing override", err)
+	}
+
+	loader.OverrideOptional = true
+	config.Host = ""
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "mixin" || loader.SkipReason(overridePath) == nil {
+		t.Errorf("got %+v, want mixin host with %s skipped", config, overridePath)
+	}
+}
+
+func TestPathMetadata(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"host": "base"}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	configPath := filepath.Join(dir, "config.json")
+	loader.WithPathMetadata(configPath, map[string]interface{}{"trusted": true})
+
+	var config map[string]interface{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if paths := loader.LoadedPaths(); len(paths) != 1 || paths[0] != configPath {
+		t.Fatalf("got loaded paths %v, want %s", paths, configPath)
+	}
+	if trusted, _ := loader.PathMetadata(configPath)["trusted"].(bool); !trusted {
+		t.Errorf("got metadata %v, want trusted", loader.PathMetadata(configPath))
+	}
+	if meta := loader.PathMetadata(filepath.Join(dir, "other.json")); meta != nil {
+		t.Errorf("got metadata %v for path without metadata", meta)
+	}
+}
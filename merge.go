@@ -0,0 +1,264 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const requiresKey = "$requires"
+
+//Checks "$requires" constraint of config file values against AppVersion.
+func (l *Loader) versionMatches(values interface{}) (bool, error) {
+	object, _ := values.(map[string]interface{})
+	requires, ok := object[requiresKey]
+	if !ok {
+		return true, nil
+	}
+
+	constraint, ok := requires.(string)
+	if !ok {
+		return false, fmt.Errorf("conf: %s must be a string", requiresKey)
+	}
+
+	return versionSatisfies(l.AppVersion, constraint)
+}
+
+//Loader merges generic representations of all config files.
+//Merge path additionally unmarshals config from the merged result once
+//all files are read instead of decoding each file into config.
+//It is used by flags that change how files are merged.
+func (l *Loader) usesMergePath() bool {
+	return l.Implements(FillMissingOnly) || len(l.ArrayEnvFilter) > 0
+}
+
+//Decodes doc into config and merges its values into merged.
+func (l *Loader) decode(doc *document, config interface{}, merged map[string]interface{}) (err error) {
+	defer func() {
+		if err != nil && doc.generated {
+			err = generatedData(err)
+		}
+	}()
+
+	if items, ok := doc.values.([]interface{}); ok && l.Implements(PositionalFields) {
+		doc.values, err = positionalObject(items, reflect.TypeOf(config))
+		if err != nil {
+			return err
+		}
+		err = doc.reencode()
+		if err != nil {
+			return err
+		}
+	}
+
+	object, isObject := doc.values.(map[string]interface{})
+	if isObject {
+		delete(object, requiresKey)
+	}
+
+	configType := reflect.TypeOf(config)
+	if isObject && configType != nil {
+		transforms := []typedTransform{}
+		if l.Implements(CoerceStringNumbers) {
+			transforms = append(transforms, coerceStringNumbers)
+		}
+		if len(l.TimeLayouts) > 0 {
+			transforms = append(transforms, parseTimeLayouts(l.TimeLayouts))
+		}
+
+		for _, transform := range transforms {
+			_, err = transformTyped(object, configType, "", transform)
+			if err != nil {
+				return err
+			}
+		}
+		if len(transforms) > 0 {
+			err = doc.reencode()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if !l.usesMergePath() || configType == nil || configType.Kind() != reflect.Ptr {
+		err = doc.unmarshal(doc.data, config)
+		if err != nil {
+			return err
+		}
+	} else {
+		//decoding into a scratch value keeps type errors attributed to the file
+		err = doc.unmarshal(doc.data, reflect.New(configType.Elem()).Interface())
+		if err != nil {
+			return err
+		}
+		if !isObject {
+			return errors.New("conf: top level value is not an object")
+		}
+	}
+
+	if isObject {
+		mergeValues(merged, object, l.Implements(FillMissingOnly))
+	}
+
+	return nil
+}
+
+//Maps items to encoded fields of struct type t in declaration order.
+func positionalObject(items []interface{}, t reflect.Type) (map[string]interface{}, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("conf: positional config requires struct config")
+	}
+
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(items) != len(names) {
+		return nil, fmt.Errorf("conf: positional config has %d values, %s has %d fields", len(items), t, len(names))
+	}
+
+	object := make(map[string]interface{}, len(items))
+	for i, item := range items {
+		object[names[i]] = item
+	}
+
+	return object, nil
+}
+
+//Returns error naming first top level key of values missing in baseKeys.
+//Nil baseKeys means no base config was loaded.
+func checkMixinKeys(values interface{}, baseKeys map[string]bool) error {
+	if baseKeys == nil {
+		return errors.New("conf: no base config loaded to check mixin keys against")
+	}
+
+	object, ok := values.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !hasKeyFold(baseKeys, key) && key != requiresKey {
+			return fmt.Errorf("conf: mixin introduces key %s missing in base config", key)
+		}
+	}
+
+	return nil
+}
+
+//Reports if keys contain key, compared case-insensitively
+//the way encoding/json matches object keys to fields.
+func hasKeyFold(keys map[string]bool, key string) bool {
+	if keys[key] {
+		return true
+	}
+	for k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func unmarshalMerged(merged map[string]interface{}, config interface{}) error {
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, config)
+}
+
+//Merges src into dst. Nested objects are merged recursively, other
+//values replace those in dst unless fillMissingOnly is set.
+func mergeValues(dst, src map[string]interface{}, fillMissingOnly bool) {
+	for key, value := range src {
+		current, exists := dst[key]
+
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := current.(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap, fillMissingOnly)
+			continue
+		}
+
+		if exists && fillMissingOnly {
+			continue
+		}
+
+		dst[key] = value
+	}
+}
+
+//Drops elements of array at dotted path which have field
+//with value other than env.
+func filterArrayEnv(merged map[string]interface{}, path, field, env string) {
+	keys := strings.Split(path, ".")
+	parent := merged
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := parent[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		parent = next
+	}
+
+	last := keys[len(keys)-1]
+	items, ok := parent[last].([]interface{})
+	if !ok {
+		return
+	}
+
+	kept := []interface{}{}
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			if value, exists := object[field]; exists && value != env {
+				continue
+			}
+		}
+		kept = append(kept, item)
+	}
+	parent[last] = kept
+}
+
+//Sets value at dotted key in merged to value encoded as JSON, creating
+//missing nested objects, so merged agrees with overridden config fields.
+func setMergedValue(merged map[string]interface{}, dottedKey string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var decoded interface{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	keys := strings.Split(dottedKey, ".")
+	parent := merged
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := parent[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			parent[key] = next
+		}
+		parent = next
+	}
+	parent[keys[len(keys)-1]] = decoded
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFillMissingOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"host": "base", "db": {"port": 1}}`,
+		"config/mixins/test.json": `{"host": "mixin", "db": {"port": 2, "name": "app"}, "debug": true}`,
+	})
+	loader := newTestLoader(t, dir, FillMissingOnly)
+
+	var config struct {
+		Host string
+		Db   struct {
+			Port int
+			Name string
+		}
+		Debug bool
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Host != "base" || config.Db.Port != 1 {
+		t.Errorf("later file overrode earlier keys: %+v", config)
+	}
+	if config.Db.Name != "app" || !config.Debug {
+		t.Errorf("later file did not fill missing keys: %+v", config)
+	}
+}
+
+func TestArrayEnvFilter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"db": {"hosts": [
+		{"env": "production", "value": "db.prod"},
+		{"env": "staging", "value": "db.stage"},
+		{"value": "db.any"}
+	]}}`})
+	t.Setenv("APP_ENV", "staging")
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	loader.ArrayEnvFilter = map[string]string{"db.hosts": "env"}
+
+	var config struct {
+		Db struct {
+			Hosts []struct {
+				Value string `json:"value"`
+			} `json:"hosts"`
+		} `json:"db"`
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts := config.Db.Hosts
+	if len(hosts) != 2 || hosts[0].Value != "db.stage" || hosts[1].Value != "db.any" {
+		t.Errorf("got hosts %+v, want staging and env-less ones", hosts)
+	}
+}
+
+func TestPositionalFields(t *testing.T) {
+	type config struct {
+		Host    string `json:"host"`
+		Port    int    `json:"port"`
+		skipped int
+		Debug   bool
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `["db.local", 5432, true]`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles|PositionalFields)
+
+	var c config
+	err := loader.Load(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Host != "db.local" || c.Port != 5432 || !c.Debug {
+		t.Errorf("got %+v", c)
+	}
+
+	writeFiles(t, dir, map[string]string{"config.json": `["db.local", 5432]`})
+	err = loader.Load(&c)
+	if err == nil || !strings.Contains(err.Error(), "positional config has 2 values, conf.config has 3 fields") {
+		t.Errorf("got %v, want length mismatch error", err)
+	}
+}
+
+func TestMixinsOverrideOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"host": "base", "port": 1}`,
+		"config/mixins/test.json": `{"host": "mixin", "prot": 2}`,
+	})
+	loader := newTestLoader(t, dir, MixinsOverrideOnly)
+
+	var config struct {
+		Host string
+		Port int
+	}
+	err := loader.Load(&config)
+	if err == nil || !strings.Contains(err.Error(), "introduces key prot") {
+		t.Fatalf("got error %v, want mixin introducing prot", err)
+	}
+
+	writeFiles(t, dir, map[string]string{"config/mixins/test.json": `{"host": "mixin"}`})
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "mixin" || config.Port != 1 {
+		t.Errorf("got %+v", config)
+	}
+
+	writeFiles(t, dir, map[string]string{"config/mixins/test.json": `{"Host": "folded"}`})
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "folded" {
+		t.Errorf("got %+v, want key matched case-insensitively", config)
+	}
+}
+
+func TestMixinsOverrideOnlyWithoutBase(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config/mixins/test.json": `{"host": "mixin"}`,
+	})
+	loader := newTestLoader(t, dir, MixinsOverrideOnly|IgnoreMissingFiles)
+
+	var config struct{ Host string }
+	err := loader.Load(&config)
+	if err == nil || !strings.Contains(err.Error(), "no base config loaded") {
+		t.Fatalf("got error %v, want no base config loaded", err)
+	}
+}
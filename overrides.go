@@ -0,0 +1,38 @@
+package conf
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type overridesKey struct{}
+
+//Returns context carrying overrides applied as the final layer
+//by LoadWithContextOverrides. Keys are top level config keys,
+//nested maps override nested keys.
+func WithOverrides(ctx context.Context, overrides map[string]interface{}) context.Context {
+	return context.WithValue(ctx, overridesKey{}, overrides)
+}
+
+func (l *Loader) applyOverrides(overrides map[string]interface{}, config interface{}, merged map[string]interface{}) error {
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		return err
+	}
+
+	//merging a copy keeps caller's map untouched
+	values := map[string]interface{}{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+	mergeValues(merged, values, false)
+	l.recordProvenance(values, "", SourceContext, false)
+
+	return nil
+}
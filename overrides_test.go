@@ -0,0 +1,43 @@
+package conf
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoadWithContextOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"host": "a", "port": 80, "db": {"name": "app", "pool": 1}}`})
+	t.Setenv("CONF_TEST_PORT", "8080")
+
+	type config struct {
+		Host string `json:"host"`
+		Port int    `json:"port" env:"CONF_TEST_PORT"`
+		Db   struct {
+			Name string `json:"name"`
+			Pool int    `json:"pool"`
+		} `json:"db"`
+	}
+
+	for _, flags := range []int{0, FillMissingOnly} {
+		loader := newTestLoader(t, dir, IgnoreMissingFiles|UseEnvOverrides|flags)
+		loader.FlatOverrides = map[string]string{"host": "b"}
+		ctx := WithOverrides(context.Background(), map[string]interface{}{
+			"host": "tenant",
+			"port": 9090,
+			"db":   map[string]interface{}{"pool": 10},
+		})
+
+		var c config
+		err := loader.LoadWithContextOverrides(ctx, &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Host != "tenant" || c.Port != 9090 || c.Db.Pool != 10 || c.Db.Name != "app" {
+			t.Errorf("flags %d: got %+v, want context overrides to win", flags, c)
+		}
+		if source, _ := loader.SourceOf("port"); source != SourceContext {
+			t.Errorf("flags %d: got port source %s", flags, source)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"time"
+)
+
+//Loads config from named pipe at path, reading until EOF.
+//Load fails when ctx is done before the writer closes the pipe.
+func (l *Loader) LoadPipe(ctx context.Context, path string, config interface{}) error {
+	l.lookupPaths = nil
+	l.lookupOrigins = nil
+	l.addLookupPath(path, OriginPipe)
+
+	start := time.Now()
+	err := l.loadPaths(ctx, readPipe, config)
+	if ctx.Err() != nil && err == ctx.Err() {
+		err = fmt.Errorf("conf: reading pipe %s: %w", path, err)
+	}
+	if l.OnComplete != nil {
+		l.OnComplete(time.Since(start), err)
+	}
+
+	return err
+}
+
+func readPipe(ctx context.Context, path string) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		f, err := os.Open(path)
+		if err != nil {
+			done <- result{nil, err}
+			return
+		}
+		defer f.Close()
+
+		data, err := ioutil.ReadAll(f)
+		done <- result{data, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		//opening the pipe for writing unblocks the reader waiting for
+		//a writer, closing it makes the reader see EOF
+		if w, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+			w.Close()
+		}
+		return nil, ctx.Err()
+	}
+}
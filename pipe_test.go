@@ -0,0 +1,76 @@
+//go:build linux || darwin
+
+package conf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLoadPipe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.fifo")
+	err := syscall.Mkfifo(path, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		w, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write([]byte(`{"name": `))
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`"piped"}`))
+		w.Close()
+	}()
+
+	loader, err := NewLoader(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct{ Name string }
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = loader.LoadPipe(ctx, path, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "piped" {
+		t.Errorf("got %+v", config)
+	}
+	for pipePath, info := range loader.PathsSeq() {
+		if pipePath != path || info.Origin != OriginPipe {
+			t.Errorf("got %s with origin %s, want %s with origin %s", pipePath, info.Origin, path, OriginPipe)
+		}
+	}
+}
+
+func TestLoadPipeTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.fifo")
+	err := syscall.Mkfifo(path, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loader, err := NewLoader(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct{ Name string }
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = loader.LoadPipe(ctx, path, &config)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "reading pipe "+path) {
+		t.Errorf("got %v, want pipe timeout error", err)
+	}
+}
@@ -0,0 +1,92 @@
+package conf
+
+const (
+	//Provenance source of values set by default struct tags.
+	SourceDefault = "default"
+
+	//Provenance source of values set by UseEnvOverrides flag.
+	SourceEnv = "env"
+
+	//Provenance source of values set by WithOverrides.
+	SourceContext = "context"
+
+	//Provenance source of values set by FlatOverrides.
+	SourceOverride = "override"
+)
+
+//Returns map of dotted config keys to the source which set them
+//in previous Load call. Source is a config file path, label passed to
+//LoadNamed or one of SourceXXX constants.
+func (l *Loader) Provenance() map[string]string {
+	return l.provenance
+}
+
+//Returns the source which last set value at dotted key, e.g. "db.port",
+//in previous Load call. Keys of nested objects are not tracked,
+//only keys of values within them.
+func (l *Loader) SourceOf(dottedKey string) (string, bool) {
+	source, ok := l.provenance[dottedKey]
+	return source, ok
+}
+
+//Records leaf keys of values as set by source. Keys already set by
+//files are kept when fillMissingOnly is set.
+func (l *Loader) recordProvenance(values interface{}, prefix, source string, fillMissingOnly bool) {
+	for _, key := range leafKeys(values, prefix, nil) {
+		l.recordSource(key, source, fillMissingOnly)
+	}
+}
+
+//Records values of doc as set by source. Values set only by files it
+//extends are attributed to them unless Load was called by LoadNamed.
+func (l *Loader) recordDocument(doc *document, source string) {
+	fillMissingOnly := l.Implements(FillMissingOnly)
+	if doc.parents == nil {
+		l.recordProvenance(doc.values, "", source, fillMissingOnly)
+		return
+	}
+
+	sources := map[string]string{}
+	for _, parent := range doc.parents {
+		parentSource := parent.path
+		if l.label != "" {
+			parentSource = l.label
+		}
+		for _, key := range parent.keys {
+			sources[key] = parentSource
+		}
+	}
+	for _, key := range doc.keys {
+		sources[key] = source
+	}
+	for key, keySource := range sources {
+		l.recordSource(key, keySource, fillMissingOnly)
+	}
+}
+
+func (l *Loader) recordSource(key, source string, fillMissingOnly bool) {
+	current, exists := l.provenance[key]
+	if exists && current != SourceDefault && fillMissingOnly {
+		return
+	}
+	l.provenance[key] = source
+}
+
+//Appends dotted keys of leaf values to keys. Top level "$requires" and
+//"$extends" keys are left out.
+func leafKeys(values interface{}, prefix string, keys []string) []string {
+	if object, ok := values.(map[string]interface{}); ok && len(object) > 0 {
+		for key, value := range object {
+			if prefix == "" && (key == requiresKey || key == extendsKey) {
+				continue
+			}
+			keys = leafKeys(value, joinKey(prefix, key), keys)
+		}
+		return keys
+	}
+
+	if prefix == "" {
+		return keys
+	}
+	return append(keys, prefix)
+}
@@ -0,0 +1,77 @@
+package conf
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProvenanceDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"port": 9090}`})
+	loader := newTestLoader(t, dir, UseDefaultTags|IgnoreMissingFiles)
+
+	var config struct {
+		Host string `json:"host" default:"localhost"`
+		Port int    `json:"port" default:"8080"`
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Host != "localhost" || config.Port != 9090 {
+		t.Errorf("got %+v", config)
+	}
+
+	provenance := loader.Provenance()
+	if provenance["host"] != SourceDefault {
+		t.Errorf("got host source %q, want %q", provenance["host"], SourceDefault)
+	}
+	if want := filepath.Join(dir, "config.json"); provenance["port"] != want {
+		t.Errorf("got port source %q, want %q", provenance["port"], want)
+	}
+}
+
+func TestLoadNamed(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"db": {"port": 5432}}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+
+	var config map[string]interface{}
+	err := loader.LoadNamed("operator-override", &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if source := loader.Provenance()["db.port"]; source != "operator-override" {
+		t.Errorf("got source %q, want label", source)
+	}
+	if loaded := loader.LoadedPaths(); len(loaded) != 1 || loaded[0] != filepath.Join(dir, "config.json") {
+		t.Errorf("got loaded paths %v", loaded)
+	}
+}
+
+func TestSourceOf(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"db": {"host": "base", "port": 5432}}`,
+		"config/mixins/test.json": `{"db": {"host": "mixin"}}`,
+	})
+	loader := newTestLoader(t, dir, 0)
+
+	var config map[string]interface{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if source, ok := loader.SourceOf("db.host"); !ok || source != filepath.Join(dir, "config", "mixins", "test.json") {
+		t.Errorf("got db.host source %q, want test mixin", source)
+	}
+	if source, ok := loader.SourceOf("db.port"); !ok || source != filepath.Join(dir, "config.json") {
+		t.Errorf("got db.port source %q, want base config", source)
+	}
+	if source, ok := loader.SourceOf("db"); ok {
+		t.Errorf("got source %q for nested object, want none", source)
+	}
+}
@@ -0,0 +1,85 @@
+package conf
+
+import (
+	"context"
+	"sync"
+)
+
+//Wraps error returned by BeforeRead.
+type deniedError struct {
+	err error
+}
+
+func (e *deniedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *deniedError) Unwrap() error {
+	return e.err
+}
+
+//Returns read calling BeforeRead before reading each path.
+func (l *Loader) guardRead(read readFunc) readFunc {
+	return func(ctx context.Context, path string) ([]byte, error) {
+		err := l.BeforeRead(path)
+		if err != nil {
+			return nil, &deniedError{err}
+		}
+		return read(ctx, path)
+	}
+}
+
+//Reads all lookup paths concurrently and returns read serving their
+//results. BeforeRead is called for each path in order before reads
+//start, so it does not need to be safe for concurrent use.
+func (l *Loader) prefetch(ctx context.Context, read readFunc) readFunc {
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	limit := l.MaxConcurrency
+	if limit <= 0 || limit > len(l.lookupPaths) {
+		limit = len(l.lookupPaths)
+	}
+
+	results := make([]result, len(l.lookupPaths))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i, path := range l.lookupPaths {
+		if l.BeforeRead != nil {
+			err := l.BeforeRead(path)
+			if err != nil {
+				results[i] = result{nil, &deniedError{err}}
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			data, err := read(ctx, path)
+			results[i] = result{data, err}
+		}(i, path)
+	}
+	wg.Wait()
+
+	fetched := make(map[string]result, len(results))
+	for i, path := range l.lookupPaths {
+		fetched[path] = results[i]
+	}
+
+	if l.BeforeRead != nil {
+		read = l.guardRead(read)
+	}
+	return func(ctx context.Context, path string) ([]byte, error) {
+		if r, ok := fetched[path]; ok {
+			return r.data, r.err
+		}
+		return read(ctx, path)
+	}
+}
@@ -0,0 +1,167 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBeforeRead(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"a": 1}`,
+		"config/mixins/test.json": `{"a": 2}`,
+	})
+	mixin := filepath.Join(dir, "config", "mixins", "test.json")
+	errDenied := errors.New("tenant may not read mixins")
+
+	loader := newTestLoader(t, dir, 0)
+	loader.BeforeRead = func(path string) error {
+		if path == mixin {
+			return errDenied
+		}
+		return nil
+	}
+
+	var config struct{ A int }
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.A != 1 {
+		t.Errorf("denied file was loaded: %+v", config)
+	}
+	if skipped := loader.SkippedPaths(); len(skipped) != 1 || skipped[0] != mixin || loader.SkipReason(mixin) != errDenied {
+		t.Errorf("got skipped %v", skipped)
+	}
+
+	loader = newTestLoader(t, dir, FailOnDeniedRead)
+	loader.BeforeRead = func(path string) error {
+		if path == mixin {
+			return errDenied
+		}
+		return nil
+	}
+	err = loader.Load(&config)
+	if err != errDenied {
+		t.Errorf("got %v, want denied error", err)
+	}
+}
+
+//Returns loader reading n argument paths named 0.json, 1.json, ...
+//with ReadFileFunc delayed by delay(i) for i-th path. Arguments are
+//restored when tb completes.
+func newDelayedLoader(tb testing.TB, n int, delay func(i int) time.Duration) *Loader {
+	loader, err := NewLoader(UseArgumentPaths | ParallelReads)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	args := []string{"app"}
+	for i := 0; i < n; i++ {
+		args = append(args, fmt.Sprintf("%d.json", i))
+	}
+	previous := os.Args
+	os.Args = args
+	tb.Cleanup(func() { os.Args = previous })
+
+	loader.ReadFileFunc = func(path string) ([]byte, error) {
+		var i int
+		fmt.Sscanf(path, "%d.json", &i)
+		time.Sleep(delay(i))
+		return []byte(fmt.Sprintf(`{"last": %d, "k%d": true}`, i, i)), nil
+	}
+
+	return loader
+}
+
+func TestParallelReadsOrder(t *testing.T) {
+	const n = 8
+	loader := newDelayedLoader(t, n, func(i int) time.Duration {
+		return time.Duration(n-i) * 5 * time.Millisecond
+	})
+
+	var running, maxRunning int32
+	readFile := loader.ReadFileFunc
+	loader.ReadFileFunc = func(path string) ([]byte, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+				break
+			}
+		}
+		return readFile(path)
+	}
+	loader.MaxConcurrency = 3
+
+	var config map[string]interface{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config["last"] != float64(n-1) || len(config) != n+1 {
+		t.Errorf("got %v, want values of all files merged in order", config)
+	}
+	if !reflect.DeepEqual(loader.LoadedPaths(), loader.LookupPaths()) {
+		t.Errorf("got loaded paths %v, want lookup order", loader.LoadedPaths())
+	}
+	if maxRunning > 3 {
+		t.Errorf("got %d concurrent reads, want at most 3", maxRunning)
+	}
+}
+
+func TestParallelReadsBeforeRead(t *testing.T) {
+	const n = 6
+	loader := newDelayedLoader(t, n, func(i int) time.Duration { return time.Millisecond })
+
+	//not synchronized, so the race detector catches concurrent calls
+	checked := []string{}
+	loader.BeforeRead = func(path string) error {
+		checked = append(checked, path)
+		if path == "3.json" {
+			return errors.New("denied")
+		}
+		return nil
+	}
+
+	var config map[string]interface{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(checked, loader.LookupPaths()) {
+		t.Errorf("got BeforeRead calls %v, want lookup order", checked)
+	}
+	if skipped := loader.SkippedPaths(); len(skipped) != 1 || skipped[0] != "3.json" || config["k3"] != nil {
+		t.Errorf("got skipped %v and %v, want 3.json denied", skipped, config)
+	}
+}
+
+func BenchmarkParallelReads(b *testing.B) {
+	for _, flags := range []int{0, ParallelReads} {
+		name := "sequential"
+		if flags != 0 {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			loader := newDelayedLoader(b, 8, func(int) time.Duration { return time.Millisecond })
+			loader.loaderFlags = UseArgumentPaths | flags
+
+			for i := 0; i < b.N; i++ {
+				var config map[string]interface{}
+				err := loader.Load(&config)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+//Replaces values of fields tagged with secret:"true" in dumps.
+const RedactedValue = "******"
+
+//Returns dotted keys of config fields tagged with secret:"true".
+func secretKeys(config interface{}) []string {
+	v := reflect.ValueOf(config)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	keys := []string{}
+	walkFields(v, "", func(field reflect.StructField, value reflect.Value, key string) error {
+		if field.Tag.Get("secret") == "true" {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+
+	return keys
+}
+
+//Returns indented JSON of config with secret fields redacted.
+func redactedJSON(config interface{}) ([]byte, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var values interface{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range secretKeys(config) {
+		redactKey(values, strings.Split(key, "."))
+	}
+
+	return json.MarshalIndent(values, "", "  ")
+}
+
+func redactKey(values interface{}, keys []string) {
+	object, ok := values.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	value, exists := object[keys[0]]
+	if !exists {
+		return
+	}
+	if len(keys) > 1 {
+		redactKey(value, keys[1:])
+		return
+	}
+	object[keys[0]] = RedactedValue
+}
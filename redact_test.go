@@ -0,0 +1,45 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDumpTo(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"host": "db.local", "db": {"user": "app", "password": "hunter2"}}`})
+
+	var dump bytes.Buffer
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	loader.DumpTo = &dump
+
+	var config struct {
+		Host string `json:"host"`
+		Db   struct {
+			User     string `json:"user"`
+			Password string `json:"password" secret:"true"`
+		} `json:"db"`
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dumped map[string]interface{}
+	err = json.Unmarshal(dump.Bytes(), &dumped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"host": "db.local",
+		"db":   map[string]interface{}{"user": "app", "password": RedactedValue},
+	}
+	if !reflect.DeepEqual(dumped, want) {
+		t.Errorf("got dump %v, want %v", dumped, want)
+	}
+	if config.Db.Password != "hunter2" {
+		t.Errorf("dump redacted loaded config")
+	}
+}
@@ -0,0 +1,72 @@
+package conf
+
+import (
+	"reflect"
+)
+
+//FieldDescriptor describes single config field.
+type FieldDescriptor struct {
+	//Path is dotted key of the field, e.g. "db.port".
+	Path string
+
+	//Type is Go type of the field.
+	Type string
+
+	//JSONTag is the raw json struct tag.
+	JSONTag string
+
+	//Default, Secret and Env hold values of corresponding struct tags.
+	Default string
+	Secret  bool
+	Env     string
+}
+
+//Returns descriptors of all fields of config struct,
+//nested structs included.
+func (l *Loader) DescribeSchema(config interface{}) []FieldDescriptor {
+	t := reflect.TypeOf(config)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return describeStruct(t, "", nil)
+}
+
+func describeStruct(t reflect.Type, prefix string, descriptors []FieldDescriptor) []FieldDescriptor {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			descriptors = describeStruct(field.Type, prefix, descriptors)
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		key := joinKey(prefix, name)
+
+		descriptors = append(descriptors, FieldDescriptor{
+			Path:    key,
+			Type:    field.Type.String(),
+			JSONTag: field.Tag.Get("json"),
+			Default: field.Tag.Get("default"),
+			Secret:  field.Tag.Get("secret") == "true",
+			Env:     field.Tag.Get("env"),
+		})
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			descriptors = describeStruct(fieldType, key, descriptors)
+		}
+	}
+
+	return descriptors
+}
@@ -0,0 +1,35 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDescribeSchema(t *testing.T) {
+	type db struct {
+		Host     string `json:"host" default:"localhost" env:"DB_HOST"`
+		Password string `json:"password" secret:"true"`
+	}
+	var config struct {
+		Db      db            `json:"db"`
+		Timeout time.Duration `json:"timeout,omitempty" default:"5s"`
+		skipped int
+	}
+
+	loader, err := NewLoader(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := loader.DescribeSchema(&config)
+	want := []FieldDescriptor{
+		{Path: "db", Type: "conf.db", JSONTag: "db"},
+		{Path: "db.host", Type: "string", JSONTag: "host", Default: "localhost", Env: "DB_HOST"},
+		{Path: "db.password", Type: "string", JSONTag: "password", Secret: true},
+		{Path: "timeout", Type: "time.Duration", JSONTag: "timeout,omitempty", Default: "5s"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
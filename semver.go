@@ -0,0 +1,150 @@
+package conf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//Checks if version satisfies constraint made of comma or space
+//separated clauses like ">=2.0.0, <3" or ">= 2.0.0 < 3". Supported
+//operators are =, !=, >, >=, < and <=, clauses without operator mean =.
+//Caret and tilde ranges follow semver conventions: ^1.2.3 means
+//>=1.2.3 <2.0.0 (^0.2.3 means >=0.2.3 <0.3.0) and ~1.2.3 means
+//>=1.2.3 <1.3.0 (~1 means >=1.0.0 <2.0.0). Wildcards like 1.x and
+//hyphen ranges are not supported.
+func versionSatisfies(version, constraint string) (bool, error) {
+	have, _, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	clauses := constraintClauses(constraint)
+	if len(clauses) == 0 {
+		return false, fmt.Errorf("conf: invalid version constraint %q", constraint)
+	}
+
+	for _, clause := range clauses {
+		i := strings.IndexFunc(clause, func(r rune) bool { return r == 'v' || unicode.IsDigit(r) })
+		if i < 0 {
+			return false, fmt.Errorf("conf: invalid version constraint %q", constraint)
+		}
+		op := clause[:i]
+		want, parts, err := parseVersion(clause[i:])
+		if err != nil {
+			return false, err
+		}
+
+		cmp := compareVersions(have, want)
+		var ok bool
+		switch op {
+		case "", "=", "==":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case "^":
+			ok = cmp >= 0 && compareVersions(have, caretLimit(want, parts)) < 0
+		case "~":
+			ok = cmp >= 0 && compareVersions(have, tildeLimit(want, parts)) < 0
+		default:
+			return false, fmt.Errorf("conf: invalid version operator %q", op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+//Splits constraint into clauses, joining operators separated by
+//spaces with the following version, e.g. ">= 1.0.0" into ">=1.0.0".
+func constraintClauses(constraint string) []string {
+	clauses := []string{}
+	op := ""
+	for _, field := range strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		if strings.Trim(field, "=!<>^~") == "" {
+			op += field
+			continue
+		}
+		clauses = append(clauses, op+field)
+		op = ""
+	}
+	if op != "" {
+		clauses = append(clauses, op)
+	}
+	return clauses
+}
+
+//Returns exclusive upper bound of caret range, bumping the first
+//non-zero of the given parts.
+func caretLimit(version [3]int, parts int) [3]int {
+	for i := 0; i < parts-1; i++ {
+		if version[i] != 0 {
+			return bumpVersion(version, i)
+		}
+	}
+	return bumpVersion(version, parts-1)
+}
+
+//Returns exclusive upper bound of tilde range, bumping minor version
+//or major version if only it is given.
+func tildeLimit(version [3]int, parts int) [3]int {
+	if parts == 1 {
+		return bumpVersion(version, 0)
+	}
+	return bumpVersion(version, 1)
+}
+
+func bumpVersion(version [3]int, part int) [3]int {
+	var bumped [3]int
+	copy(bumped[:part], version[:part])
+	bumped[part] = version[part] + 1
+	return bumped
+}
+
+//Parses major.minor.patch version, missing parts default to 0.
+//Returns number of parts given. Pre-release and build suffixes are ignored.
+func parseVersion(s string) ([3]int, int, error) {
+	var version [3]int
+
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return version, 0, fmt.Errorf("conf: invalid version %q", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return version, 0, fmt.Errorf("conf: invalid version %q", s)
+		}
+		version[i] = n
+	}
+
+	return version, len(parts), nil
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionSatisfies(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+	}{
+		{"2.1.0", ">=2.0.0", true},
+		{"1.9.9", ">=2.0.0", false},
+		{"2.1.0", ">= 2.0.0", true},
+		{"3.0.0", ">= 2.0.0 < 3", false},
+		{"2.5.0", ">=2.0.0, <3", true},
+		{"v2.0.0", "2", true},
+		{"2.0.1", "!= 2.0.1", false},
+		{"1.9.0", "^1.2.3", true},
+		{"2.0.0", "^1.2.3", false},
+		{"1.2.2", "^1.2.3", false},
+		{"0.2.9", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"0.0.4", "^0.0.3", false},
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.9.0", "~1", true},
+		{"2.0.0", "~1", false},
+	}
+	for _, test := range tests {
+		got, err := versionSatisfies(test.version, test.constraint)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.version, test.constraint, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s %s: got %v, want %v", test.version, test.constraint, got, test.want)
+		}
+	}
+
+	for _, constraint := range []string{"", ">=", "1.x", "=> 1"} {
+		_, err := versionSatisfies("1.0.0", constraint)
+		if err == nil {
+			t.Errorf("invalid constraint %q accepted", constraint)
+		}
+	}
+}
+
+func TestRequiresConstraint(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"config.json":             `{"$requires": ">= 2.0.0", "a": 1}`,
+		"config/mixins/test.json": `{"$requires": "^3", "a": 2}`,
+	})
+	loader := newTestLoader(t, dir, 0)
+	loader.AppVersion = "2.4.0"
+
+	var config struct{ A int }
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mixin := filepath.Join(dir, "config", "mixins", "test.json")
+	if config.A != 1 {
+		t.Errorf("got a=%d, want value of satisfied base only", config.A)
+	}
+	if reason := loader.SkipReason(mixin); reason == nil || !strings.Contains(reason.Error(), "version mismatch") {
+		t.Errorf("got skip reason %v, want version mismatch", reason)
+	}
+}
@@ -0,0 +1,35 @@
+package conf
+
+import (
+	"context"
+	"time"
+)
+
+//Source fetches named config files from arbitrary backends,
+//e.g. HTTP, S3 or key-value stores.
+type Source interface {
+	Fetch(ctx context.Context, name string) ([]byte, error)
+}
+
+//Loads config from names fetched from src in order, with the same
+//decoding, merging and IgnoreXXX semantics as Load. Errors recognised
+//by IsNotFound are handled like missing config files. Any other fetch
+//error, e.g. unreachable backend, fails the load even with
+//IgnoreMissingFiles flag set, so config is never silently partial.
+func (l *Loader) LoadSource(ctx context.Context, src Source, names []string, config interface{}) error {
+	l.lookupPaths = nil
+	l.lookupOrigins = nil
+	for _, name := range names {
+		l.addLookupPath(name, OriginSource)
+	}
+
+	start := time.Now()
+	err := l.loadPaths(ctx, func(ctx context.Context, name string) ([]byte, error) {
+		return src.Fetch(ctx, name)
+	}, config)
+	if l.OnComplete != nil {
+		l.OnComplete(time.Since(start), err)
+	}
+
+	return err
+}
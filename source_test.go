@@ -0,0 +1,86 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+type memorySource map[string]string
+
+func (m memorySource) Fetch(ctx context.Context, name string) ([]byte, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return []byte(data), nil
+}
+
+func TestLoadSource(t *testing.T) {
+	src := memorySource{
+		"base":     `{"host": "base", "port": 80}`,
+		"override": `{"host": "override"}`,
+	}
+	loader, err := NewLoader(IgnoreMissingFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	err = loader.LoadSource(context.Background(), src, []string{"base", "missing", "override"}, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Host != "override" || config.Port != 80 {
+		t.Errorf("got %+v", config)
+	}
+	if skipped := loader.SkippedPaths(); len(skipped) != 1 || skipped[0] != "missing" {
+		t.Errorf("got skipped %v", skipped)
+	}
+	if source, _ := loader.SourceOf("host"); source != "override" {
+		t.Errorf("got host source %s", source)
+	}
+}
+
+type failingSource struct {
+	memorySource
+	failing string
+	err     error
+}
+
+func (f failingSource) Fetch(ctx context.Context, name string) ([]byte, error) {
+	if name == f.failing {
+		return nil, f.err
+	}
+	return f.memorySource.Fetch(ctx, name)
+}
+
+func TestLoadSourceFetchError(t *testing.T) {
+	fetchErr := errors.New("dial tcp: connection refused")
+	src := failingSource{
+		memorySource: memorySource{"base": `{"host": "base"}`},
+		failing:      "remote",
+		err:          fetchErr,
+	}
+	loader, err := NewLoader(IgnoreMissingFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct {
+		Host string `json:"host"`
+	}
+	err = loader.LoadSource(context.Background(), src, []string{"base", "remote"}, &config)
+	if !errors.Is(err, fetchErr) {
+		t.Fatalf("got error %v, want %v", err, fetchErr)
+	}
+	if skipped := loader.SkippedPaths(); len(skipped) != 0 {
+		t.Errorf("got skipped %v, want none", skipped)
+	}
+}
@@ -0,0 +1,224 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+//LoaderState is an opaque copy of loader configuration
+//captured by Snapshot. Hooks and load results are not included.
+type LoaderState struct {
+	rootPath       string
+	preservedArgs  int
+	argumentMode   ArgumentPathsMode
+	execSubPath    string
+	rootPathEnvVar string
+	ciEnvVar       string
+	baseFileNames  []string
+	envVar         string
+	envAliases     map[string]string
+	arrayEnvFilter map[string]string
+	flatOverrides  map[string]string
+	rawCapture     []string
+	timeLayouts    []string
+	overridePath   string
+	overrideOpt    bool
+	appVersion     string
+	templateData   map[string]interface{}
+	maxConcurrency int
+	loadTimeout    time.Duration
+	loaderFlags    int
+}
+
+//Captures loader configuration so it can be restored later.
+func (l *Loader) Snapshot() LoaderState {
+	return LoaderState{
+		rootPath:       l.RootPath,
+		preservedArgs:  l.PreservedArgs,
+		argumentMode:   l.ArgumentPathsMode,
+		execSubPath:    l.ExecSubPath,
+		rootPathEnvVar: l.RootPathEnvVar,
+		ciEnvVar:       l.CIEnvVar,
+		baseFileNames:  copyStrings(l.BaseFileNames),
+		envVar:         l.EnvVar,
+		envAliases:     copyStringMap(l.EnvAliases),
+		arrayEnvFilter: copyStringMap(l.ArrayEnvFilter),
+		flatOverrides:  copyStringMap(l.FlatOverrides),
+		rawCapture:     copyStrings(l.RawCapture),
+		timeLayouts:    copyStrings(l.TimeLayouts),
+		overridePath:   l.OverridePath,
+		overrideOpt:    l.OverrideOptional,
+		appVersion:     l.AppVersion,
+		templateData:   copyValueMap(l.TemplateData),
+		maxConcurrency: l.MaxConcurrency,
+		loadTimeout:    l.LoadTimeout,
+		loaderFlags:    l.loaderFlags,
+	}
+}
+
+//Restores loader configuration captured by Snapshot. Contradicting
+//flags are reported in Diagnostics even with StrictFlags flag set.
+func (l *Loader) Restore(state LoaderState) {
+	l.RootPath = state.rootPath
+	l.PreservedArgs = state.preservedArgs
+	l.ArgumentPathsMode = state.argumentMode
+	l.ExecSubPath = state.execSubPath
+	l.RootPathEnvVar = state.rootPathEnvVar
+	l.CIEnvVar = state.ciEnvVar
+	l.BaseFileNames = copyStrings(state.baseFileNames)
+	l.EnvVar = state.envVar
+	l.EnvAliases = copyStringMap(state.envAliases)
+	l.ArrayEnvFilter = copyStringMap(state.arrayEnvFilter)
+	l.FlatOverrides = copyStringMap(state.flatOverrides)
+	l.RawCapture = copyStrings(state.rawCapture)
+	l.TimeLayouts = copyStrings(state.timeLayouts)
+	l.OverridePath = state.overridePath
+	l.OverrideOptional = state.overrideOpt
+	l.AppVersion = state.appVersion
+	l.TemplateData = copyValueMap(state.templateData)
+	l.MaxConcurrency = state.maxConcurrency
+	l.LoadTimeout = state.loadTimeout
+	l.loaderFlags = state.loaderFlags
+	l.flagWarnings = flagConflicts(l.loaderFlags)
+	l.flagWarningsLogged = false
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func copyValueMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+type exportedConfig struct {
+	Flags             map[string]bool
+	RootPath          string
+	PreservedArgs     int
+	ArgumentPathsMode ArgumentPathsMode
+	ExecSubPath       string
+	RootPathEnvVar    string
+	CIEnvVar          string
+	BaseFileNames     []string
+	EnvVar            string
+	EnvAliases        map[string]string
+	ArrayEnvFilter    map[string]string
+	FlatOverrides     map[string]string
+	RawCapture        []string
+	TimeLayouts       []string
+	OverridePath      string
+	OverrideOptional  bool
+	AppVersion        string
+	TemplateData      map[string]interface{}
+	MaxConcurrency    int
+	LoadTimeout       string
+}
+
+//Serializes loader configuration to JSON, with flags as named booleans.
+//Hooks are not included. Use ImportConfig to recreate the loader.
+func (l *Loader) ExportConfig() ([]byte, error) {
+	flags := map[string]bool{}
+	for _, f := range flagNames {
+		flags[f.name] = l.Implements(f.flag)
+	}
+
+	return json.MarshalIndent(exportedConfig{
+		Flags:             flags,
+		RootPath:          l.RootPath,
+		PreservedArgs:     l.PreservedArgs,
+		ArgumentPathsMode: l.ArgumentPathsMode,
+		ExecSubPath:       l.ExecSubPath,
+		RootPathEnvVar:    l.RootPathEnvVar,
+		CIEnvVar:          l.CIEnvVar,
+		BaseFileNames:     l.BaseFileNames,
+		EnvVar:            l.EnvVar,
+		EnvAliases:        l.EnvAliases,
+		ArrayEnvFilter:    l.ArrayEnvFilter,
+		FlatOverrides:     l.FlatOverrides,
+		RawCapture:        l.RawCapture,
+		TimeLayouts:       l.TimeLayouts,
+		OverridePath:      l.OverridePath,
+		OverrideOptional:  l.OverrideOptional,
+		AppVersion:        l.AppVersion,
+		TemplateData:      l.TemplateData,
+		MaxConcurrency:    l.MaxConcurrency,
+		LoadTimeout:       l.LoadTimeout.String(),
+	}, "", "  ")
+}
+
+//Creates loader from configuration serialized by ExportConfig.
+func ImportConfig(data []byte) (*Loader, error) {
+	var exported exportedConfig
+	err := json.Unmarshal(data, &exported)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := 0
+	for name, set := range exported.Flags {
+		flag, ok := flagByName(name)
+		if !ok {
+			return nil, fmt.Errorf("conf: unknown flag %s", name)
+		}
+		if set {
+			flags |= flag
+		}
+	}
+
+	loadTimeout := time.Duration(0)
+	if exported.LoadTimeout != "" {
+		loadTimeout, err = time.ParseDuration(exported.LoadTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	loader, err := NewLoader(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	loader.RootPath = exported.RootPath
+	loader.PreservedArgs = exported.PreservedArgs
+	loader.ArgumentPathsMode = exported.ArgumentPathsMode
+	loader.ExecSubPath = exported.ExecSubPath
+	loader.RootPathEnvVar = exported.RootPathEnvVar
+	loader.CIEnvVar = exported.CIEnvVar
+	loader.BaseFileNames = exported.BaseFileNames
+	loader.EnvVar = exported.EnvVar
+	loader.EnvAliases = exported.EnvAliases
+	loader.ArrayEnvFilter = exported.ArrayEnvFilter
+	loader.FlatOverrides = exported.FlatOverrides
+	loader.RawCapture = exported.RawCapture
+	loader.TimeLayouts = exported.TimeLayouts
+	loader.OverridePath = exported.OverridePath
+	loader.OverrideOptional = exported.OverrideOptional
+	loader.AppVersion = exported.AppVersion
+	loader.TemplateData = exported.TemplateData
+	loader.MaxConcurrency = exported.MaxConcurrency
+	loader.LoadTimeout = loadTimeout
+
+	return loader, nil
+}
@@ -0,0 +1,85 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	loader, err := NewLoader(IgnoreMissingFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.RootPath = "/etc/app"
+	loader.BaseFileNames = []string{"app.json"}
+	loader.EnvAliases = map[string]string{"prod": "production"}
+	loader.LoadTimeout = time.Second
+
+	state := loader.Snapshot()
+
+	loader.RootPath = "/tmp"
+	loader.BaseFileNames[0] = "other.json"
+	loader.EnvAliases["prod"] = "staging"
+	loader.LoadTimeout = 0
+	loader.loaderFlags = UseTest
+
+	loader.Restore(state)
+	if loader.RootPath != "/etc/app" || loader.LoadTimeout != time.Second || loader.loaderFlags != IgnoreMissingFiles {
+		t.Errorf("fields not restored: %+v", loader)
+	}
+	if !reflect.DeepEqual(loader.BaseFileNames, []string{"app.json"}) || !reflect.DeepEqual(loader.EnvAliases, map[string]string{"prod": "production"}) {
+		t.Errorf("snapshot shares slices or maps with loader: %v %v", loader.BaseFileNames, loader.EnvAliases)
+	}
+	if !reflect.DeepEqual(loader.Snapshot(), state) {
+		t.Errorf("got %+v, want %+v", loader.Snapshot(), state)
+	}
+}
+
+func TestExportImportConfig(t *testing.T) {
+	loader, err := NewLoader(IgnoreMissingFiles | UseEnvExpansion | ResolveExtends)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader.RootPath = "/etc/app"
+	loader.ArgumentPathsMode = ArgRequire
+	loader.BaseFileNames = []string{"app.json", "app.yaml"}
+	loader.EnvAliases = map[string]string{"prod": "production"}
+	loader.FlatOverrides = map[string]string{"db.port": "5432"}
+	loader.TimeLayouts = []string{"2006-01-02"}
+	loader.OverridePath = "/etc/app/override.json"
+	loader.OverrideOptional = true
+	loader.AppVersion = "1.2.3"
+	loader.TemplateData = map[string]interface{}{"region": "eu"}
+	loader.MaxConcurrency = 4
+	loader.LoadTimeout = 5 * time.Second
+
+	data, err := loader.ExportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ImportConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.loaderFlags != loader.loaderFlags {
+		t.Errorf("got flags %b, want %b", imported.loaderFlags, loader.loaderFlags)
+	}
+	if !reflect.DeepEqual(imported.Snapshot(), loader.Snapshot()) {
+		t.Errorf("got %+v, want %+v", imported.Snapshot(), loader.Snapshot())
+	}
+
+	reexported, err := imported.ExportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reexported) != string(data) {
+		t.Errorf("got %s, want %s", reexported, data)
+	}
+
+	_, err = ImportConfig([]byte(`{"Flags": {"NoSuchFlag": true}}`))
+	if err == nil {
+		t.Error("expected error importing unknown flag")
+	}
+}
@@ -0,0 +1,101 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Logger is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+var flagNames = []struct {
+	flag int
+	name string
+}{
+	{UseTest, "UseTest"},
+	{UseDotUser, "UseDotUser"},
+	{UseArgumentPaths, "UseArgumentPaths"},
+	{UseExecutablePath, "UseExecutablePath"},
+	{IgnoreMissingFiles, "IgnoreMissingFiles"},
+	{IgnoreInvalidFiles, "IgnoreInvalidFiles"},
+	{UseEnvExpansion, "UseEnvExpansion"},
+	{NoMixins, "NoMixins"},
+	{FillMissingOnly, "FillMissingOnly"},
+	{UseDefaultTags, "UseDefaultTags"},
+	{VerifyChecksums, "VerifyChecksums"},
+	{MergeBaseFiles, "MergeBaseFiles"},
+	{ValidateNonFatal, "ValidateNonFatal"},
+	{UseBinaryNameConfig, "UseBinaryNameConfig"},
+	{CoerceStringNumbers, "CoerceStringNumbers"},
+	{UseEnvRootPath, "UseEnvRootPath"},
+	{FailOnDeniedRead, "FailOnDeniedRead"},
+	{UseEnvMixin, "UseEnvMixin"},
+	{UseEnvOverrides, "UseEnvOverrides"},
+	{PositionalFields, "PositionalFields"},
+	{UseTemplateExpansion, "UseTemplateExpansion"},
+	{StrictExtensions, "StrictExtensions"},
+	{SkipUserMixinInCI, "SkipUserMixinInCI"},
+	{ParallelReads, "ParallelReads"},
+	{ResolveExtends, "ResolveExtends"},
+	{MixinsOverrideOnly, "MixinsOverrideOnly"},
+	{StrictFlags, "StrictFlags"},
+}
+
+var flagConflictRules = []struct {
+	flags  int
+	reason string
+}{
+	{NoMixins | UseTest, "NoMixins disables test mixin enabled by UseTest"},
+	{NoMixins | UseDotUser, "NoMixins disables user mixin configured by UseDotUser"},
+	{NoMixins | UseEnvMixin, "NoMixins disables env mixin enabled by UseEnvMixin"},
+	{NoMixins | SkipUserMixinInCI, "NoMixins already skips user mixin affected by SkipUserMixinInCI"},
+	{NoMixins | MixinsOverrideOnly, "NoMixins makes MixinsOverrideOnly have no effect"},
+	{UseBinaryNameConfig | MergeBaseFiles, "UseBinaryNameConfig uses single base file, MergeBaseFiles has no effect"},
+}
+
+//Returns errors describing contradicting flags.
+func flagConflicts(flags int) []error {
+	conflicts := []error{}
+	for _, rule := range flagConflictRules {
+		if flags&rule.flags == rule.flags {
+			conflicts = append(conflicts, fmt.Errorf("conf: contradicting flags: %s", rule.reason))
+		}
+	}
+	return conflicts
+}
+
+func flagByName(name string) (int, bool) {
+	for _, f := range flagNames {
+		if f.name == name {
+			return f.flag, true
+		}
+	}
+	return 0, false
+}
+
+//Logs single line describing flags, resolved root path, lookup paths
+//and results of previous Load call.
+func (l *Loader) LogSummary(logger Logger) {
+	flags := []string{}
+	for _, f := range flagNames {
+		if l.Implements(f.flag) {
+			flags = append(flags, f.name)
+		}
+	}
+
+	paths := make([]string, len(l.lookupPaths))
+	for i, path := range l.lookupPaths {
+		paths[i] = fmt.Sprintf("%s(%s)", path, l.lookupOrigins[i])
+	}
+
+	logger.Printf("conf: flags=%s root=%s paths=[%s] loaded=%d skipped=%d fingerprint=%s",
+		strings.Join(flags, ","),
+		l.rootPath(),
+		strings.Join(paths, " "),
+		len(l.loadedPaths),
+		len(l.skippedPaths),
+		l.fingerprint,
+	)
+}
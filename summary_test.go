@@ -0,0 +1,130 @@
+package conf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type captureLogger struct {
+	lines []string
+}
+
+func (c *captureLogger) Printf(format string, v ...interface{}) {
+	c.lines = append(c.lines, fmt.Sprintf(format, v...))
+}
+
+func TestLogSummary(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"a": 1}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+
+	var config map[string]interface{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := &captureLogger{}
+	loader.LogSummary(logger)
+	if len(logger.lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(logger.lines))
+	}
+
+	line := logger.lines[0]
+	for _, want := range []string{
+		"flags=UseTest,IgnoreMissingFiles",
+		"root=" + dir,
+		filepath.Join(dir, "config.json") + "(base)",
+		"(test)",
+		"loaded=1",
+		"skipped=1",
+		"fingerprint=" + loader.Fingerprint(),
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("summary %q misses %q", line, want)
+		}
+	}
+}
+
+func TestLogSummaryResolvedRoot(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CONFIG_DIR", dir)
+	loader := newTestLoader(t, "/unused", UseEnvRootPath)
+
+	logger := &captureLogger{}
+	loader.LogSummary(logger)
+	if !strings.Contains(logger.lines[0], "root="+dir+" ") {
+		t.Errorf("summary %q does not report resolved root %s", logger.lines[0], dir)
+	}
+}
+
+func TestFlagConflicts(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{}`})
+	loader := newTestLoader(t, dir, NoMixins|MixinsOverrideOnly)
+
+	diagnostics := loader.Diagnostics()
+	if len(diagnostics) != 2 {
+		t.Fatalf("got diagnostics %v after NewLoader, want 2 conflicts", diagnostics)
+	}
+	for _, diagnostic := range diagnostics {
+		if !strings.HasPrefix(diagnostic.Error(), "conf: contradicting flags:") {
+			t.Errorf("got diagnostic %v", diagnostic)
+		}
+	}
+
+	logger := &captureLogger{}
+	loader.Logger = logger
+	var config map[string]interface{}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loader.Diagnostics()) != 2 || len(logger.lines) != 2 {
+		t.Fatalf("got diagnostics %v and log %v after Load, want 2 conflicts", loader.Diagnostics(), logger.lines)
+	}
+	if logger.lines[0] != diagnostics[0].Error() {
+		t.Errorf("got log line %q, want %q", logger.lines[0], diagnostics[0])
+	}
+
+	for i := 0; i < 2; i++ {
+		err = loader.Load(&config)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(loader.Diagnostics()) != 2 || len(logger.lines) != 2 {
+		t.Errorf("got diagnostics %v and log %v after reloads, want conflicts logged once", loader.Diagnostics(), logger.lines)
+	}
+
+	state := loader.Snapshot()
+	clean := loader.Snapshot()
+	clean.loaderFlags = IgnoreMissingFiles
+	loader.Restore(clean)
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loader.Diagnostics()) != 0 {
+		t.Errorf("got diagnostics %v after restoring flags without conflicts", loader.Diagnostics())
+	}
+	loader.Restore(state)
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loader.Diagnostics()) != 2 || len(logger.lines) != 4 {
+		t.Errorf("got diagnostics %v and log %v after restoring conflicting flags", loader.Diagnostics(), logger.lines)
+	}
+
+	_, err = NewLoader(NoMixins | UseTest | StrictFlags)
+	if err == nil || !strings.Contains(err.Error(), "NoMixins disables test mixin") {
+		t.Errorf("got error %v, want NoMixins and UseTest conflict", err)
+	}
+	_, err = NewLoader(UseBinaryNameConfig | MergeBaseFiles | StrictFlags)
+	if err == nil || !strings.Contains(err.Error(), "MergeBaseFiles has no effect") {
+		t.Errorf("got error %v, want UseBinaryNameConfig and MergeBaseFiles conflict", err)
+	}
+}
@@ -0,0 +1,356 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//Returns name of struct field as used by encoding/json
+//or empty string if field is not encoded.
+func jsonName(field reflect.StructField) string {
+	if field.PkgPath != "" && !field.Anonymous {
+		return ""
+	}
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+//Sets zero value fields to their default tag value, recording
+//set fields in provenance.
+func applyDefaults(v reflect.Value, prefix string, provenance map[string]string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			err := applyDefaults(value, prefix, provenance)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		key := joinKey(prefix, name)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			err := applyDefaults(value, key, provenance)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		def, ok := field.Tag.Lookup("default")
+		if !ok || !value.IsZero() {
+			continue
+		}
+
+		err := setFromString(value, def)
+		if err != nil {
+			return fmt.Errorf("conf: invalid default for %s: %v", key, err)
+		}
+		provenance[key] = SourceDefault
+	}
+
+	return nil
+}
+
+func (l *Loader) applyFlatOverrides(config interface{}, merged map[string]interface{}) error {
+	v := reflect.ValueOf(config)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("conf: FlatOverrides require pointer to struct config")
+	}
+	v = v.Elem()
+
+	for key, override := range l.FlatOverrides {
+		field, ok := fieldByJSONName(v, key)
+		if !ok {
+			err := fmt.Errorf("conf: unknown override key %s", key)
+			if !l.Implements(IgnoreInvalidFiles) {
+				return err
+			}
+			l.diagnose(err)
+			continue
+		}
+
+		err := setFromString(field, override)
+		if err != nil {
+			return fmt.Errorf("conf: invalid override for %s: %v", key, err)
+		}
+		err = setMergedValue(merged, key, field.Interface())
+		if err != nil {
+			return err
+		}
+		l.provenance[key] = SourceOverride
+	}
+
+	return nil
+}
+
+//Finds top level field of struct v the way encoding/json matches keys.
+func fieldByJSONName(v reflect.Value, key string) (reflect.Value, bool) {
+	var folded reflect.Value
+	found := false
+
+	for i := 0; i < v.NumField(); i++ {
+		name := jsonName(v.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		if name == key {
+			return v.Field(i), true
+		}
+		if !found && strings.EqualFold(name, key) {
+			folded, found = v.Field(i), true
+		}
+	}
+
+	return folded, found
+}
+
+//Calls fn for each encoded field of struct v, recursing into nested structs.
+func walkFields(v reflect.Value, prefix string, fn func(field reflect.StructField, value reflect.Value, key string) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			err := walkFields(value, prefix, fn)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		key := joinKey(prefix, name)
+
+		err := fn(field, value, key)
+		if err != nil {
+			return err
+		}
+
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct {
+			err := walkFields(value, key, fn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//Sets fields with env tag from environment variables named by the tag.
+//All variables are parsed before any field is set and parse errors
+//are returned together.
+func (l *Loader) applyEnvOverrides(v reflect.Value, merged map[string]interface{}) error {
+	type override struct {
+		key    string
+		field  reflect.Value
+		parsed reflect.Value
+	}
+	overrides := []override{}
+	errs := []error{}
+
+	walkFields(v, "", func(field reflect.StructField, value reflect.Value, key string) error {
+		name := field.Tag.Get("env")
+		if name == "" {
+			return nil
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+
+		parsed := reflect.New(value.Type()).Elem()
+		err := setFromString(parsed, raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("conf: invalid %s for %s: %v", name, key, err))
+			return nil
+		}
+		overrides = append(overrides, override{key, value, parsed})
+		return nil
+	})
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, o := range overrides {
+		o.field.Set(o.parsed)
+		err := setMergedValue(merged, o.key, o.parsed.Interface())
+		if err != nil {
+			return err
+		}
+		l.provenance[o.key] = SourceEnv
+	}
+
+	return nil
+}
+
+//Checks string fields against comma separated values of their enum tag.
+//Empty fields are considered unset and are not checked.
+func (l *Loader) checkEnums(v reflect.Value) error {
+	return walkFields(v, "", func(field reflect.StructField, value reflect.Value, key string) error {
+		enum, ok := field.Tag.Lookup("enum")
+		if !ok || value.Kind() != reflect.String || value.String() == "" {
+			return nil
+		}
+
+		for _, allowed := range strings.Split(enum, ",") {
+			if value.String() == strings.TrimSpace(allowed) {
+				return nil
+			}
+		}
+
+		err := fmt.Errorf("conf: invalid value %q for %s, allowed: %s", value.String(), key, enum)
+		if !l.Implements(IgnoreInvalidFiles) {
+			return err
+		}
+		l.diagnose(err)
+		return nil
+	})
+}
+
+//Checks fields against rules of their validate tag, e.g.
+//validate:"min=1,max=65535" for numbers or validate:"minlen=3"
+//for strings, slices and maps. Violations are returned together.
+func (l *Loader) checkValidateTags(v reflect.Value) error {
+	errs := []error{}
+
+	err := walkFields(v, "", func(field reflect.StructField, value reflect.Value, key string) error {
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok {
+			return nil
+		}
+
+		for _, rule := range strings.Split(rules, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fmt.Errorf("conf: invalid validate rule %q for %s", rule, key)
+			}
+
+			var actual float64
+			switch name {
+			case "min", "max":
+				switch value.Kind() {
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					actual = float64(value.Int())
+				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+					actual = float64(value.Uint())
+				case reflect.Float32, reflect.Float64:
+					actual = value.Float()
+				default:
+					return fmt.Errorf("conf: validate rule %s requires numeric field %s", name, key)
+				}
+			case "minlen", "maxlen":
+				switch value.Kind() {
+				case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+					actual = float64(value.Len())
+				default:
+					return fmt.Errorf("conf: validate rule %s requires string, slice or map field %s", name, key)
+				}
+			default:
+				return fmt.Errorf("conf: unknown validate rule %q for %s", rule, key)
+			}
+
+			if (strings.HasPrefix(name, "min") && actual < limit) || (strings.HasPrefix(name, "max") && actual > limit) {
+				errs = append(errs, fmt.Errorf("conf: %s violates %s=%s", key, name, arg))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if !l.Implements(IgnoreInvalidFiles) {
+		return errors.Join(errs...)
+	}
+	for _, err := range errs {
+		l.diagnose(err)
+	}
+	return nil
+}
+
+//Parses s according to the kind of v and stores the result in v.
+func setFromString(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlatOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"port": 80, "host": "a"}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+	loader.FlatOverrides = map[string]string{"port": "8080", "host": "b"}
+
+	var config struct {
+		Port int    `json:"port"`
+		Host string `json:"host"`
+	}
+	err := loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 8080 || config.Host != "b" {
+		t.Errorf("got %+v, want overridden port and host", config)
+	}
+	if loader.Provenance()["port"] != SourceOverride {
+		t.Errorf("got port source %q", loader.Provenance()["port"])
+	}
+
+	loader.FlatOverrides = map[string]string{"missing": "1"}
+	err = loader.Load(&config)
+	if err == nil {
+		t.Error("unknown override key accepted")
+	}
+}
+
+func TestEnumTags(t *testing.T) {
+	type config struct {
+		Mode  string `json:"mode" enum:"fast,safe"`
+		Level string `json:"level" enum:"debug,info"`
+	}
+
+	tests := []struct {
+		data    string
+		wantErr string
+	}{
+		{`{"mode": "fast", "level": "info"}`, ""},
+		{`{"mode": "safe"}`, ""},
+		{`{"mode": "fsat"}`, `conf: invalid value "fsat" for mode, allowed: fast,safe`},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"config.json": test.data})
+		loader := newTestLoader(t, dir, IgnoreMissingFiles)
+
+		err := loader.Load(&config{})
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", test.data, err)
+		}
+		if test.wantErr != "" && (err == nil || err.Error() != test.wantErr) {
+			t.Errorf("%s: got %v, want %s", test.data, err, test.wantErr)
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"port": 80, "host": "a"}`})
+
+	type config struct {
+		Port    int           `json:"port" env:"CONF_TEST_PORT"`
+		Host    string        `json:"host" env:"CONF_TEST_HOST"`
+		Timeout time.Duration `json:"timeout" env:"CONF_TEST_TIMEOUT"`
+	}
+
+	t.Setenv("CONF_TEST_HOST", "b")
+	t.Setenv("CONF_TEST_PORT", "http")
+	t.Setenv("CONF_TEST_TIMEOUT", "soon")
+	loader := newTestLoader(t, dir, IgnoreMissingFiles|UseEnvOverrides)
+
+	var c config
+	err := loader.Load(&c)
+	if err == nil {
+		t.Fatal("malformed env vars accepted")
+	}
+	for _, want := range []string{"CONF_TEST_PORT", "CONF_TEST_TIMEOUT"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if c.Host != "a" {
+		t.Errorf("field set before all env vars were validated: %+v", c)
+	}
+
+	t.Setenv("CONF_TEST_PORT", "8080")
+	t.Setenv("CONF_TEST_TIMEOUT", "5s")
+	err = loader.Load(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 8080 || c.Host != "b" || c.Timeout != 5*time.Second {
+		t.Errorf("got %+v", c)
+	}
+	if source, _ := loader.SourceOf("port"); source != SourceEnv {
+		t.Errorf("got port source %s", source)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"port": 70000, "name": "ab", "hosts": ["a"]}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+
+	var config struct {
+		Port  int      `json:"port" validate:"min=1,max=65535"`
+		Name  string   `json:"name" validate:"minlen=3"`
+		Hosts []string `json:"hosts" validate:"minlen=1,maxlen=3"`
+	}
+	err := loader.Load(&config)
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	for _, want := range []string{"conf: port violates max=65535", "conf: name violates minlen=3"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q misses %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "hosts") {
+		t.Errorf("valid field reported: %v", err)
+	}
+}
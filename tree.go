@@ -0,0 +1,93 @@
+package conf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+//Prints config loaded in previous Load call as indented tree. Each value
+//is annotated with its source from Provenance, values of fields tagged
+//with secret:"true" are redacted.
+func (l *Loader) Tree(w io.Writer) error {
+	if l.lastConfig == nil {
+		return errors.New("conf: no config loaded")
+	}
+
+	data, err := redactedJSON(l.lastConfig)
+	if err != nil {
+		return err
+	}
+
+	var values interface{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+
+	secrets := map[string]bool{}
+	for _, key := range secretKeys(l.lastConfig) {
+		secrets[key] = true
+	}
+
+	return l.printTree(w, values, "", 0, secrets)
+}
+
+func (l *Loader) printTree(w io.Writer, values interface{}, prefix string, depth int, secrets map[string]bool) error {
+	object, ok := values.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	indent := strings.Repeat("  ", depth)
+	for _, key := range keys {
+		value := object[key]
+		dottedKey := joinKey(prefix, key)
+
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			_, err := fmt.Fprintf(w, "%s%s\n", indent, key)
+			if err != nil {
+				return err
+			}
+			err = l.printTree(w, nested, dottedKey, depth+1, secrets)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		notes := []string{}
+		if source, ok := l.provenance[dottedKey]; ok {
+			notes = append(notes, source)
+		}
+		if secrets[dottedKey] {
+			encoded = []byte(RedactedValue)
+			notes = append(notes, "secret")
+		}
+
+		line := fmt.Sprintf("%s%s: %s", indent, key, encoded)
+		if len(notes) > 0 {
+			line += fmt.Sprintf("  (%s)", strings.Join(notes, ", "))
+		}
+		_, err = fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package conf
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"config.json": `{"host": "db.local", "db": {"user": "app", "password": "hunter2"}}`})
+	loader := newTestLoader(t, dir, IgnoreMissingFiles)
+
+	var tree bytes.Buffer
+	err := loader.Tree(&tree)
+	if err == nil {
+		t.Error("expected error before Load")
+	}
+
+	var config struct {
+		Host string `json:"host"`
+		Db   struct {
+			User     string `json:"user"`
+			Password string `json:"password" secret:"true"`
+		} `json:"db"`
+	}
+	err = loader.Load(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = loader.Tree(&tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(dir, "config.json")
+	want := strings.Join([]string{
+		"db",
+		`  password: ` + RedactedValue + `  (` + configPath + `, secret)`,
+		`  user: "app"  (` + configPath + `)`,
+		`host: "db.local"  (` + configPath + `)`,
+		"",
+	}, "\n")
+	if tree.String() != want {
+		t.Errorf("got tree\n%s\nwant\n%s", tree.String(), want)
+	}
+	if strings.Contains(tree.String(), "hunter2") {
+		t.Error("tree contains secret value")
+	}
+}